@@ -0,0 +1,66 @@
+package gomcp
+
+import (
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// Feature represents an optional MCP feature that the application implements on top of the MCPServer
+// and wants to advertise to the clients.
+type Feature int
+
+const (
+	FeatureToolsListChanged Feature = iota
+	FeatureResourcesListChanged
+	FeatureResourcesSubscribe
+	FeaturePromptsListChanged
+	FeatureLogging
+	FeatureCompletions
+)
+
+// WithFeatures enables the given features, advertising them in the capabilities returned on initialize.
+func (m *MCPServer) WithFeatures(features ...Feature) *MCPServer {
+	for _, feature := range features {
+		m.features[feature] = true
+	}
+	return m
+}
+
+// WithExperimental advertises a non-standard experimental capability with the given name and configuration.
+func (m *MCPServer) WithExperimental(name string, config any) *MCPServer {
+	if m.experimental == nil {
+		m.experimental = make(map[string]any)
+	}
+	m.experimental[name] = config
+	return m
+}
+
+// capabilities returns the capabilities of the server, based on what is registered on it
+// and on the enabled features.
+func (m *MCPServer) capabilities() types.Capabilities {
+	capabilities := types.Capabilities{Experimental: m.experimental}
+
+	if len(m.tools) > 0 || m.features[FeatureToolsListChanged] {
+		capabilities.Tools = &types.ToolsCapability{ListChanged: m.features[FeatureToolsListChanged]}
+	}
+
+	if len(m.resources)+len(m.resourceTemplates) > 0 || m.features[FeatureResourcesListChanged] || m.features[FeatureResourcesSubscribe] {
+		capabilities.Resources = &types.ResourcesCapability{
+			Subscribe:   m.features[FeatureResourcesSubscribe],
+			ListChanged: m.features[FeatureResourcesListChanged],
+		}
+	}
+
+	if len(m.prompts) > 0 || m.features[FeaturePromptsListChanged] {
+		capabilities.Prompts = &types.PromptsCapability{ListChanged: m.features[FeaturePromptsListChanged]}
+	}
+
+	if m.features[FeatureLogging] {
+		capabilities.Logging = &types.LoggingCapability{}
+	}
+
+	if m.features[FeatureCompletions] {
+		capabilities.Completions = &types.CompletionsCapability{}
+	}
+
+	return capabilities
+}
@@ -0,0 +1,131 @@
+package gomcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// contextKey is the type of the keys of the values stored by the server in the request contexts.
+type contextKey int
+
+const (
+	requestIdKey contextKey = iota
+	sessionKey
+	loggerKey
+	progressKey
+	senderKey
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// CancelledError is the cause of the cancellation of the context of a request
+// cancelled by the client with notifications/cancelled.
+type CancelledError struct {
+	Reason string
+}
+
+// Error returns the description of the cancellation, including the reason sent by the client.
+func (e *CancelledError) Error() string {
+	if e.Reason == "" {
+		return "request cancelled by the client"
+	}
+	return "request cancelled by the client: " + e.Reason
+}
+
+// newRequestContext returns the context passed to the handlers of req, derived from the context of the
+// session, so it is cancelled when the session is closed, when parent is done or when the client cancels
+// the request. It carries the request id, the session, a logger prefixed with the method and the id of the
+// request, the progress reporter and the sender of parent.
+func (m *MCPServer) newRequestContext(parent context.Context, session *Session, req *types.JSONRPCRequest) (context.Context, context.CancelFunc) {
+	logger := log.New(log.Writer(), fmt.Sprintf("%s[%s %s] ", log.Prefix(), req.Method, req.Id), log.Flags())
+	sender := senderFromContext(parent)
+
+	ctx := context.WithValue(session.ctx, requestIdKey, req.Id)
+	ctx = context.WithValue(ctx, sessionKey, session)
+	ctx = context.WithValue(ctx, loggerKey, logger)
+	if sender != nil {
+		ctx = context.WithValue(ctx, senderKey, sender)
+	}
+	if reporter := newProgressReporter(session, sender, req, m.progressInterval); reporter != nil {
+		ctx = context.WithValue(ctx, progressKey, reporter)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(parent, func() { cancel(context.Cause(parent)) })
+
+	// the initialize request can't be cancelled
+	if req.Method == Initialize {
+		return ctx, func() {
+			stop()
+			cancel(nil)
+		}
+	}
+
+	session.track(req.Id.String(), cancel)
+	return ctx, func() {
+		stop()
+		session.untrack(req.Id.String())
+		cancel(nil)
+	}
+}
+
+// ContextWithSender returns a copy of ctx carrying sender. The messages sent by the server to the client
+// while handling the requests passed to HandleContext with the returned context are delivered through
+// sender instead of the sender of the session, e.g. on the stream of an HTTP response.
+func ContextWithSender(ctx context.Context, sender Sender) context.Context {
+	return context.WithValue(ctx, senderKey, sender)
+}
+
+// senderFromContext returns the sender carried by ctx, or nil.
+func senderFromContext(ctx context.Context) Sender {
+	sender, _ := ctx.Value(senderKey).(Sender)
+	return sender
+}
+
+// RequestIdFromContext returns the id of the request being handled, and false when ctx is not a request context.
+func RequestIdFromContext(ctx context.Context) (types.RequestId, bool) {
+	id, ok := ctx.Value(requestIdKey).(types.RequestId)
+	return id, ok
+}
+
+// SessionFromContext returns the session of the request being handled, or nil when ctx is not a request context.
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionKey).(*Session)
+	return session
+}
+
+// ClientInfoFromContext returns the information sent on initialize by the client of the request being handled,
+// and false when ctx is not a request context.
+func ClientInfoFromContext(ctx context.Context) (types.ClientInfo, bool) {
+	session := SessionFromContext(ctx)
+	if session == nil {
+		return types.ClientInfo{}, false
+	}
+
+	return session.ClientInfo(), true
+}
+
+// CancellationReasonFromContext returns the reason sent by the client when it cancelled the request,
+// and false when the request was not cancelled by the client.
+func CancellationReasonFromContext(ctx context.Context) (string, bool) {
+	var cancelled *CancelledError
+	if errors.As(context.Cause(ctx), &cancelled) {
+		return cancelled.Reason, true
+	}
+
+	return "", false
+}
+
+// LoggerFromContext returns the logger of the request being handled, whose messages are prefixed with
+// the method and the id of the request. The standard logger is returned when ctx is not a request context.
+func LoggerFromContext(ctx context.Context) *log.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*log.Logger); ok {
+		return logger
+	}
+
+	return log.Default()
+}
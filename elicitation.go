@@ -0,0 +1,95 @@
+package gomcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/mcpunzo/gomcp/internal/schema_validator"
+	"github.com/mcpunzo/gomcp/types"
+)
+
+const ElicitationCreate = "elicitation/create"
+
+var (
+	ErrElicitationNotSupported      = errors.New("client does not support elicitation")
+	ErrElicitationTargetNotStruct   = errors.New("elicitation target must be a pointer to a struct")
+	ErrElicitationFieldNotPrimitive = errors.New("elicitation fields must be strings, numbers, integers or booleans")
+	ErrElicitationInvalidContent    = errors.New("elicitation content does not match the requested schema")
+)
+
+// Elicit asks the user of the client of the request being handled for the input described by target,
+// a pointer to a struct whose fields are strings, numbers, integers or booleans. The requested schema
+// is generated from the struct as for the tool parameters, so it can be annotated with jsonschema tags.
+// It returns the action taken by the user, and when the user accepted the content is decoded into target.
+// ctx must be the context of the request, and the client must have advertised the elicitation capability.
+// The request is cancelled when ctx is done, or after DefaultRequestTimeout when ctx has no deadline.
+func Elicit(ctx context.Context, message string, target any) (types.ElicitAction, error) {
+	session := SessionFromContext(ctx)
+	if session == nil {
+		return "", ErrNoSession
+	}
+
+	if session.ClientCapabilities().Elicitation == nil {
+		return "", ErrElicitationNotSupported
+	}
+
+	schema, err := elicitationSchema(target)
+	if err != nil {
+		return "", err
+	}
+
+	var result types.ElicitResult
+	if err := session.request(ctx, ElicitationCreate, types.NewElicitParams(message, schema), &result); err != nil {
+		return "", err
+	}
+
+	if result.Action != types.ElicitActionAccept {
+		return result.Action, nil
+	}
+
+	violations, err := schema_validator.Validate(schema, result.Content)
+	if err != nil {
+		return "", err
+	}
+	if len(violations) > 0 {
+		return "", fmt.Errorf("%w: %v", ErrElicitationInvalidContent, violations)
+	}
+
+	contentBytes, _ := json.Marshal(result.Content)
+	if err := json.Unmarshal(contentBytes, target); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrElicitationInvalidContent, err)
+	}
+
+	return result.Action, nil
+}
+
+// elicitationSchema returns the requested schema of an elicitation decoded into target.
+func elicitationSchema(target any) (map[string]any, error) {
+	t := reflect.TypeOf(target)
+	if t == nil || t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return nil, ErrElicitationTargetNotStruct
+	}
+
+	schema, err := schemaOf(t.Elem(), map[reflect.Type]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+	for name, p := range props {
+		prop, _ := p.(map[string]any)
+		switch prop["type"] {
+		case "string", "number", "integer", "boolean":
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrElicitationFieldNotPrimitive, name)
+		}
+	}
+
+	// the requested schema is restricted to type, properties and required
+	delete(schema, "additionalProperties")
+
+	return schema, nil
+}
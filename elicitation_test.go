@@ -0,0 +1,175 @@
+package gomcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+func TestElicit(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeWithCapabilitiesTest(t, mcpserver, `{"elicitation":{}}`)
+	messages := senderTest(mcpserver)
+
+	type Confirmation struct {
+		Confirm bool   `json:"confirm" jsonschema:"description=Confirm the deletion"`
+		Reason  string `json:"reason,omitempty"`
+	}
+
+	var action types.ElicitAction
+	var confirmation Confirmation
+	var err error
+	mcpserver.AddTool(types.NewContextTool("delete", "delete", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		confirmation = Confirmation{}
+		action, err = Elicit(ctx, "Delete all the files?", &confirmation)
+		return types.NewToolResult(nil), nil
+	}))
+
+	table := []struct {
+		clientResponse       string
+		expectedAction       types.ElicitAction
+		expectedConfirmation Confirmation
+		expectedErr          error
+	}{
+		{
+			`{"jsonrpc":"2.0","id":1,"result":{"action":"accept","content":{"confirm":true,"reason":"cleanup"}}}`,
+			types.ElicitActionAccept,
+			Confirmation{Confirm: true, Reason: "cleanup"},
+			nil,
+		},
+		{
+			`{"jsonrpc":"2.0","id":2,"result":{"action":"decline"}}`,
+			types.ElicitActionDecline,
+			Confirmation{},
+			nil,
+		},
+		{
+			`{"jsonrpc":"2.0","id":3,"result":{"action":"cancel"}}`,
+			types.ElicitActionCancel,
+			Confirmation{},
+			nil,
+		},
+		{
+			`{"jsonrpc":"2.0","id":4,"result":{"action":"accept","content":{"confirm":"yes"}}}`,
+			"",
+			Confirmation{},
+			ErrElicitationInvalidContent,
+		},
+	}
+
+	expectedRequest := map[string]any{
+		"message": "Delete all the files?",
+		"requestedSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"confirm": map[string]any{"type": "boolean", "description": "Confirm the deletion"},
+				"reason":  map[string]any{"type": "string"},
+			},
+			"required": []any{"confirm"},
+		},
+	}
+
+	for _, test := range table {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"delete"}}`)
+		}()
+
+		var request types.JSONRPCRequest
+		if err := json.Unmarshal([]byte(<-messages), &request); err != nil {
+			t.Fatalf("Expected nil but got %v", err)
+		}
+		if request.Method != ElicitationCreate || !reflect.DeepEqual(request.Params, expectedRequest) {
+			t.Errorf("Expected %#v but got %#v", expectedRequest, request.Params)
+		}
+
+		mcpserver.Handle(test.clientResponse)
+		<-done
+
+		if action != test.expectedAction {
+			t.Errorf("Expected %v but got %v", test.expectedAction, action)
+		}
+
+		if confirmation != test.expectedConfirmation {
+			t.Errorf("Expected %#v but got %#v", test.expectedConfirmation, confirmation)
+		}
+
+		if !errors.Is(err, test.expectedErr) {
+			t.Errorf("Expected %#v but got %#v", test.expectedErr, err)
+		}
+	}
+}
+
+func TestElicitWithInvalidTarget(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeWithCapabilitiesTest(t, mcpserver, `{"elicitation":{}}`)
+	senderTest(mcpserver)
+
+	type Nested struct {
+		Paths []string `json:"paths"`
+	}
+
+	table := []struct {
+		target   any
+		expected error
+	}{
+		{
+			nil,
+			ErrElicitationTargetNotStruct,
+		},
+		{
+			struct{}{},
+			ErrElicitationTargetNotStruct,
+		},
+		{
+			&Nested{},
+			ErrElicitationFieldNotPrimitive,
+		},
+	}
+
+	var errs []error
+	mcpserver.AddTool(types.NewContextTool("delete", "delete", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		for _, test := range table {
+			_, err := Elicit(ctx, "", test.target)
+			errs = append(errs, err)
+		}
+		return types.NewToolResult(nil), nil
+	}))
+
+	mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"delete"}}`)
+
+	for i, test := range table {
+		if !errors.Is(errs[i], test.expected) {
+			t.Errorf("Expected %#v but got %#v", test.expected, errs[i])
+		}
+	}
+}
+
+func TestElicitNotSupported(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeWithCapabilitiesTest(t, mcpserver, `{"sampling":{}}`)
+
+	var err error
+	mcpserver.AddTool(types.NewContextTool("delete", "delete", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		_, err = Elicit(ctx, "", &struct{}{})
+		return types.NewToolResult(nil), nil
+	}))
+
+	mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"delete"}}`)
+
+	if !errors.Is(err, ErrElicitationNotSupported) {
+		t.Errorf("Expected %#v but got %#v", ErrElicitationNotSupported, err)
+	}
+
+	if _, err := Elicit(context.Background(), "", &struct{}{}); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Expected %#v but got %#v", ErrNoSession, err)
+	}
+}
@@ -1,24 +1,44 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/mcpunzo/gomcp"
-	"github.com/mcpunzo/gomcp/internal/transport"
+	"github.com/mcpunzo/gomcp/transport"
 	"github.com/mcpunzo/gomcp/types"
 )
 
 type CalculatorParams struct {
-	A int `json:"a"`
-	B int `json:"b"`
+	A int `json:"a" jsonschema:"description=The first operand"`
+	B int `json:"b" jsonschema:"description=The second operand"`
 }
 
 func main() {
 	mcp := gomcp.New("gomcp-calculator", "v1.0.0").WithTransport(transport.NewHttpTransport(8080))
 	addPlusTool(mcp)
 	addMinusTool(mcp)
-	mcp.Run()
+
+	// on SIGINT or SIGTERM the requests in progress get 10 seconds to complete
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := mcp.Shutdown(shutdownCtx); err != nil {
+			log.Print(err)
+		}
+	}()
+
+	if err := mcp.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func addPlusTool(mcp *gomcp.MCPServer) {
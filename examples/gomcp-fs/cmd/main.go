@@ -1,17 +1,22 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/mcpunzo/gomcp"
-	"github.com/mcpunzo/gomcp/internal/transport"
+	"github.com/mcpunzo/gomcp/transport"
 	"github.com/mcpunzo/gomcp/types"
 )
 
 type FSReaderParams struct {
-	Path string `json:"path"`
+	Path string `json:"path" jsonschema:"description=The path of the directory"`
 }
 
 func main() {
@@ -20,11 +25,13 @@ func main() {
 	addCdTool(mcp)
 	addPwdTool(mcp)
 
-	mcp.Run()
+	if err := mcp.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 }
 
 func addLsTool(mcp *gomcp.MCPServer) {
-	ls_handler := func(params FSReaderParams) (*types.ToolResult, error) {
+	ls_handler := func(ctx context.Context, params FSReaderParams) (*types.ToolResult, error) {
 		log.Print(params.Path)
 
 		path := params.Path
@@ -32,6 +39,10 @@ func addLsTool(mcp *gomcp.MCPServer) {
 			path = "."
 		}
 
+		if err := checkRoots(ctx, path); err != nil {
+			return nil, err
+		}
+
 		fileInfo, err := os.Stat(path)
 		if err != nil {
 			return nil, err
@@ -59,9 +70,13 @@ func addLsTool(mcp *gomcp.MCPServer) {
 }
 
 func addCdTool(mcp *gomcp.MCPServer) {
-	cd_handler := func(params FSReaderParams) (*types.ToolResult, error) {
+	cd_handler := func(ctx context.Context, params FSReaderParams) (*types.ToolResult, error) {
 		log.Print(params.Path)
 
+		if err := checkRoots(ctx, params.Path); err != nil {
+			return nil, err
+		}
+
 		err := os.Chdir(params.Path)
 		if err != nil {
 			return nil, err
@@ -98,3 +113,34 @@ func addPwdTool(mcp *gomcp.MCPServer) {
 
 	mcp.AddToolFunc("pwd", "print the current working directory", pwd_handler)
 }
+
+// checkRoots verifies that path is inside one of the roots exposed by the client,
+// when the client supports roots.
+func checkRoots(ctx context.Context, path string) error {
+	roots, err := gomcp.RootsFromContext(ctx)
+	if errors.Is(err, gomcp.ErrRootsNotSupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	for _, root := range roots {
+		rootURL, err := url.Parse(root.URI)
+		if err != nil || rootURL.Scheme != "file" {
+			continue
+		}
+
+		rel, err := filepath.Rel(rootURL.Path, absPath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s is outside of the roots exposed by the client", path)
+}
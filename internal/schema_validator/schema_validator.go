@@ -0,0 +1,292 @@
+package schema_validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// Validate validates value against a JSON Schema and returns the list of violations, empty when value is valid.
+// Both schema and value are normalized through their JSON encoding, so they can be built from any Go value.
+// The supported keywords are type, enum, const, properties, required, additionalProperties, items,
+// minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength, pattern,
+// minItems, maxItems, uniqueItems, allOf, anyOf, oneOf and not.
+func Validate(schema any, value any) ([]types.SchemaViolation, error) {
+	var normalizedSchema, normalizedValue any
+	if err := normalize(schema, &normalizedSchema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := normalize(value, &normalizedValue); err != nil {
+		return nil, fmt.Errorf("invalid value: %w", err)
+	}
+
+	v := &validator{violations: []types.SchemaViolation{}}
+	v.validate(normalizedSchema, normalizedValue, "")
+	return v.violations, nil
+}
+
+func normalize(in any, out *any) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+type validator struct {
+	violations []types.SchemaViolation
+}
+
+func (v *validator) addViolation(path, keyword, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+	v.violations = append(v.violations, *types.NewSchemaViolation(path, keyword, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) validate(s any, value any, path string) {
+	schema, ok := s.(map[string]any)
+	if !ok {
+		// the boolean schema false rejects everything
+		if b, isBool := s.(bool); isBool && !b {
+			v.addViolation(path, "false", "no value is allowed")
+		}
+		return
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		v.addViolation(path, "type", "must be of type %v, got %v", typeNames(t), jsonType(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(e any) bool { return reflect.DeepEqual(e, value) }) {
+		v.addViolation(path, "enum", "must be one of %v", formatValues(enum))
+	}
+
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		v.addViolation(path, "const", "must be equal to %v", formatValues([]any{c}))
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		v.validateObject(schema, val, path)
+	case []any:
+		v.validateArray(schema, val, path)
+	case string:
+		v.validateString(schema, val, path)
+	case float64:
+		v.validateNumber(schema, val, path)
+	}
+
+	v.validateComposition(schema, value, path)
+}
+
+func (v *validator) validateObject(schema map[string]any, value map[string]any, path string) {
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, present := value[name]; !present {
+				v.addViolation(path+"/"+escapePointer(name), "required", "is required")
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+	for _, name := range slices.Sorted(maps.Keys(value)) {
+		propPath := path + "/" + escapePointer(name)
+		if prop, ok := props[name]; ok {
+			v.validate(prop, value[name], propPath)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.addViolation(propPath, "additionalProperties", "is not an allowed property")
+			}
+		case map[string]any:
+			v.validate(additional, value[name], propPath)
+		}
+	}
+}
+
+func (v *validator) validateArray(schema map[string]any, value []any, path string) {
+	if items, ok := schema["items"]; ok {
+		for i, item := range value {
+			v.validate(items, item, path+"/"+strconv.Itoa(i))
+		}
+	}
+
+	if min, ok := number(schema["minItems"]); ok && float64(len(value)) < min {
+		v.addViolation(path, "minItems", "must have at least %v items", min)
+	}
+
+	if max, ok := number(schema["maxItems"]); ok && float64(len(value)) > max {
+		v.addViolation(path, "maxItems", "must have at most %v items", max)
+	}
+
+	if unique, _ := schema["uniqueItems"].(bool); unique {
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if reflect.DeepEqual(value[i], value[j]) {
+					v.addViolation(path, "uniqueItems", "must not contain duplicate items (%d and %d)", i, j)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) validateString(schema map[string]any, value string, path string) {
+	length := float64(utf8.RuneCountInString(value))
+
+	if min, ok := number(schema["minLength"]); ok && length < min {
+		v.addViolation(path, "minLength", "must be at least %v characters long", min)
+	}
+
+	if max, ok := number(schema["maxLength"]); ok && length > max {
+		v.addViolation(path, "maxLength", "must be at most %v characters long", max)
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err == nil && !re.MatchString(value) {
+			v.addViolation(path, "pattern", "must match the pattern %q", pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(schema map[string]any, value float64, path string) {
+	if min, ok := number(schema["minimum"]); ok && value < min {
+		v.addViolation(path, "minimum", "must be >= %v", min)
+	}
+
+	if max, ok := number(schema["maximum"]); ok && value > max {
+		v.addViolation(path, "maximum", "must be <= %v", max)
+	}
+
+	if min, ok := number(schema["exclusiveMinimum"]); ok && value <= min {
+		v.addViolation(path, "exclusiveMinimum", "must be > %v", min)
+	}
+
+	if max, ok := number(schema["exclusiveMaximum"]); ok && value >= max {
+		v.addViolation(path, "exclusiveMaximum", "must be < %v", max)
+	}
+
+	if multiple, ok := number(schema["multipleOf"]); ok && multiple > 0 {
+		quotient := value / multiple
+		if math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			v.addViolation(path, "multipleOf", "must be a multiple of %v", multiple)
+		}
+	}
+}
+
+func (v *validator) validateComposition(schema map[string]any, value any, path string) {
+	if all, ok := schema["allOf"].([]any); ok {
+		for _, s := range all {
+			v.validate(s, value, path)
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok && v.countValid(anyOf, value, path) == 0 {
+		v.addViolation(path, "anyOf", "must match at least one of the schemas")
+	}
+
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		if count := v.countValid(oneOf, value, path); count != 1 {
+			v.addViolation(path, "oneOf", "must match exactly one of the schemas, matched %d", count)
+		}
+	}
+
+	if not, ok := schema["not"]; ok && v.countValid([]any{not}, value, path) == 1 {
+		v.addViolation(path, "not", "must not match the schema")
+	}
+}
+
+// countValid returns how many of schemas value is valid against.
+func (v *validator) countValid(schemas []any, value any, path string) int {
+	count := 0
+	for _, s := range schemas {
+		sub := &validator{}
+		sub.validate(s, value, path)
+		if len(sub.violations) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+func matchesType(t any, value any) bool {
+	switch t := t.(type) {
+	case string:
+		return matchesTypeName(t, value)
+	case []any:
+		return slices.ContainsFunc(t, func(name any) bool {
+			n, _ := name.(string)
+			return matchesTypeName(n, value)
+		})
+	default:
+		return true
+	}
+}
+
+func matchesTypeName(name string, value any) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == name
+	}
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func typeNames(t any) string {
+	if names, ok := t.([]any); ok {
+		return formatValues(names)
+	}
+	return fmt.Sprint(t)
+}
+
+func formatValues(values []any) string {
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+func number(v any) (float64, bool) {
+	n, ok := v.(float64)
+	return n, ok
+}
+
+// escapePointer escapes name to be used as a JSON Pointer (RFC 6901) token.
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
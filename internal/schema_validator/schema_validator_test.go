@@ -0,0 +1,88 @@
+package schema_validator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+func TestValidate(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":   map[string]any{"type": "string", "pattern": "^[a-z]+$", "maxLength": 5},
+			"count":  map[string]any{"type": "integer", "exclusiveMinimum": 0, "multipleOf": 2},
+			"ratio":  map[string]any{"type": "number", "minimum": 0, "exclusiveMaximum": 1},
+			"ids":    map[string]any{"type": "array", "items": map[string]any{"type": "integer"}, "minItems": 1, "maxItems": 3, "uniqueItems": true},
+			"mode":   map[string]any{"const": "fast"},
+			"id":     map[string]any{"anyOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "integer"}}},
+			"kind":   map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"enum": []any{"a"}}}},
+			"nested": map[string]any{"type": []any{"object", "null"}, "required": []string{"a/b"}, "not": map[string]any{"required": []string{"forbidden"}}},
+			"labels": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+		},
+		"required":             []string{"name"},
+		"additionalProperties": false,
+	}
+
+	table := []struct {
+		value    any
+		expected []types.SchemaViolation
+	}{
+		{
+			map[string]any{"name": "abc", "count": 4, "ratio": 0.5, "ids": []int{1, 2}, "mode": "fast", "id": 3, "kind": "b", "nested": map[string]any{"a/b": nil}, "labels": map[string]string{"x": "y"}},
+			[]types.SchemaViolation{},
+		},
+		{
+			map[string]any{"name": "ABCDEF", "count": 3, "ratio": 1},
+			[]types.SchemaViolation{
+				*types.NewSchemaViolation("/count", "multipleOf", "must be a multiple of 2"),
+				*types.NewSchemaViolation("/name", "maxLength", "must be at most 5 characters long"),
+				*types.NewSchemaViolation("/name", "pattern", `must match the pattern "^[a-z]+$"`),
+				*types.NewSchemaViolation("/ratio", "exclusiveMaximum", "must be < 1"),
+			},
+		},
+		{
+			map[string]any{"name": "a", "count": 0, "ids": []int{1, 1, 2, 3}, "mode": "slow", "id": true, "kind": "a"},
+			[]types.SchemaViolation{
+				*types.NewSchemaViolation("/count", "exclusiveMinimum", "must be > 0"),
+				*types.NewSchemaViolation("/id", "anyOf", "must match at least one of the schemas"),
+				*types.NewSchemaViolation("/ids", "maxItems", "must have at most 3 items"),
+				*types.NewSchemaViolation("/ids", "uniqueItems", "must not contain duplicate items (0 and 1)"),
+				*types.NewSchemaViolation("/kind", "oneOf", "must match exactly one of the schemas, matched 2"),
+				*types.NewSchemaViolation("/mode", "const", `must be equal to ["fast"]`),
+			},
+		},
+		{
+			map[string]any{"name": "a", "ids": []int{}, "nested": map[string]any{"forbidden": 1}, "labels": map[string]any{"x": 1}, "other": 1},
+			[]types.SchemaViolation{
+				*types.NewSchemaViolation("/ids", "minItems", "must have at least 1 items"),
+				*types.NewSchemaViolation("/labels/x", "type", "must be of type string, got number"),
+				*types.NewSchemaViolation("/nested/a~1b", "required", "is required"),
+				*types.NewSchemaViolation("/nested", "not", "must not match the schema"),
+				*types.NewSchemaViolation("/other", "additionalProperties", "is not an allowed property"),
+			},
+		},
+		{
+			[]any{},
+			[]types.SchemaViolation{*types.NewSchemaViolation("/", "type", "must be of type object, got array")},
+		},
+	}
+
+	for _, test := range table {
+		actual, err := Validate(schema, test.value)
+		if err != nil {
+			t.Fatalf("Expected nil but got %v", err)
+		}
+
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %v but got %v", test.expected, actual)
+		}
+	}
+}
+
+func TestValidateWithInvalidSchema(t *testing.T) {
+	if _, err := Validate(map[string]any{"type": func() {}}, nil); err == nil {
+		t.Errorf("Expected error but got nil")
+	}
+}
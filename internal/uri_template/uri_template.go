@@ -0,0 +1,171 @@
+package uri_template
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrUnclosedExpression = errors.New("unclosed expression in uri template")
+	ErrEmptyExpression    = errors.New("empty expression in uri template")
+	ErrInvalidVarName     = errors.New("invalid variable name in uri template")
+)
+
+var varNameRegexp = regexp.MustCompile(`^([A-Za-z0-9_.]|%[0-9A-Fa-f]{2})+$`)
+
+// URITemplate is an RFC 6570 URI template that can be matched against concrete URIs.
+type URITemplate struct {
+	raw   string
+	re    *regexp.Regexp
+	names []string
+	ops   []byte
+}
+
+// Parse parses an RFC 6570 URI template (levels 1 to 4).
+// Prefix modifiers are accepted but ignored when matching; explode modifiers allow
+// the value to span multiple separators.
+func Parse(template string) (*URITemplate, error) {
+	var pattern strings.Builder
+	var names []string
+	var ops []byte
+
+	pattern.WriteString("^")
+	rest := template
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+
+		pattern.WriteString(regexp.QuoteMeta(rest[:start]))
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, ErrUnclosedExpression
+		}
+
+		expr := rest[start+1 : start+end]
+		rest = rest[start+end+1:]
+
+		if expr == "" {
+			return nil, ErrEmptyExpression
+		}
+
+		op := byte(0)
+		if strings.IndexByte("+#./;?&", expr[0]) >= 0 {
+			op = expr[0]
+			expr = expr[1:]
+		}
+
+		vars := strings.Split(expr, ",")
+		for i, v := range vars {
+			name, explode := v, false
+			if n, ok := strings.CutSuffix(name, "*"); ok {
+				name, explode = n, true
+			}
+			name, _, _ = strings.Cut(name, ":")
+
+			if !varNameRegexp.MatchString(name) {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidVarName, name)
+			}
+
+			pattern.WriteString(varPattern(op, name, i == 0, len(vars) > 1, explode))
+			names = append(names, name)
+			ops = append(ops, op)
+		}
+	}
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &URITemplate{raw: template, re: re, names: names, ops: ops}, nil
+}
+
+// varPattern returns the regular expression matching a single variable of an expression.
+func varPattern(op byte, name string, first, multiple, explode bool) string {
+	switch op {
+	case '+', '#':
+		prefix := ""
+		if first && op == '#' {
+			prefix = "#"
+		} else if !first {
+			prefix = ","
+		}
+		if multiple {
+			return regexp.QuoteMeta(prefix) + `([^,?#]*)`
+		}
+		return regexp.QuoteMeta(prefix) + `([^?#]*)`
+	case '.':
+		if explode {
+			return `((?:\.[^/?#]*)?)`
+		}
+		return `(?:\.([^/?#.]*))?`
+	case '/':
+		if explode {
+			return `((?:/[^?#]*)?)`
+		}
+		return `(?:/([^/?#]*))?`
+	case ';':
+		return `(?:;` + regexp.QuoteMeta(name) + `(?:=([^;/?#]*))?)?`
+	case '?', '&':
+		return `(?:[?&]` + regexp.QuoteMeta(name) + `=([^&#]*))?`
+	default:
+		prefix := ""
+		if !first {
+			prefix = ","
+		}
+		if explode {
+			return prefix + `([^/?#]*)`
+		}
+		return prefix + `([^/?#,]*)`
+	}
+}
+
+// String returns the raw template.
+func (t *URITemplate) String() string {
+	return t.raw
+}
+
+// Names returns the names of the variables of the template, in order of appearance.
+func (t *URITemplate) Names() []string {
+	return t.names
+}
+
+// Match matches uri against the template and returns the extracted variables.
+// Variables not present in uri are omitted from the returned map.
+func (t *URITemplate) Match(uri string) (map[string]string, bool) {
+	groups := t.re.FindStringSubmatchIndex(uri)
+	if groups == nil {
+		return nil, false
+	}
+
+	vars := map[string]string{}
+	for i, name := range t.names {
+		start, end := groups[2*(i+1)], groups[2*(i+1)+1]
+		if start < 0 {
+			continue
+		}
+
+		value := uri[start:end]
+		unescape := url.PathUnescape
+		switch t.ops[i] {
+		case '.', '/':
+			value = strings.TrimPrefix(value, string(t.ops[i]))
+		case '?', '&':
+			unescape = url.QueryUnescape
+		}
+
+		if unescaped, err := unescape(value); err == nil {
+			value = unescaped
+		}
+		vars[name] = value
+	}
+
+	return vars, true
+}
@@ -0,0 +1,64 @@
+package uri_template
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	table := []struct {
+		template string
+		uri      string
+		expected map[string]string
+		matches  bool
+	}{
+		{"file:///{path}", "file:///notes.txt", map[string]string{"path": "notes.txt"}, true},
+		{"file:///{path}", "file:///dir/notes.txt", nil, false},
+		{"file:///{+path}", "file:///dir/notes.txt", map[string]string{"path": "dir/notes.txt"}, true},
+		{"db://{table}/{id}", "db://users/42", map[string]string{"table": "users", "id": "42"}, true},
+		{"db://{table}/{id}", "db://users", nil, false},
+		{"users/{name}", "users/john%20doe", map[string]string{"name": "john doe"}, true},
+		{"repo{/owner,name}", "repo/golang/go", map[string]string{"owner": "golang", "name": "go"}, true},
+		{"search{?q,lang}", "search?q=mcp+go&lang=en", map[string]string{"q": "mcp go", "lang": "en"}, true},
+		{"search{?q,lang}", "search?q=mcp", map[string]string{"q": "mcp"}, true},
+		{"doc{#section}", "doc#intro", map[string]string{"section": "intro"}, true},
+		{"file{.ext}", "file.json", map[string]string{"ext": "json"}, true},
+		{"static://config", "static://config", map[string]string{}, true},
+	}
+
+	for _, test := range table {
+		tmpl, err := Parse(test.template)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %v: %v", test.template, err)
+		}
+
+		actual, ok := tmpl.Match(test.uri)
+		if ok != test.matches {
+			t.Errorf("%v on %v: expected match %v but got %v", test.template, test.uri, test.matches, ok)
+			continue
+		}
+
+		if ok && !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("%v on %v: expected %v but got %v", test.template, test.uri, test.expected, actual)
+		}
+	}
+}
+
+func TestParseWithInvalidTemplate(t *testing.T) {
+	table := []struct {
+		template string
+		expected error
+	}{
+		{"file:///{path", ErrUnclosedExpression},
+		{"file:///{}", ErrEmptyExpression},
+		{"file:///{pa th}", ErrInvalidVarName},
+	}
+
+	for _, test := range table {
+		_, err := Parse(test.template)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("expected %v but got %v", test.expected, err)
+		}
+	}
+}
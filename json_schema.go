@@ -0,0 +1,343 @@
+package gomcp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSchemaTag = errors.New("invalid jsonschema tag")
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// jsonField describes how a struct field is encoded in JSON.
+type jsonField struct {
+	name      string
+	tagged    bool // the name comes from the json tag
+	omitempty bool // omitempty or omitzero
+	asString  bool // the ",string" option
+}
+
+// parseJSONField returns how field is encoded in JSON, and false when it is not encoded at all.
+func parseJSONField(field reflect.StructField) (jsonField, bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return jsonField{}, false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return jsonField{}, false
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	options := strings.Split(opts, ",")
+
+	jf := jsonField{
+		name:      name,
+		tagged:    name != "",
+		omitempty: slices.Contains(options, "omitempty") || slices.Contains(options, "omitzero"),
+		asString:  slices.Contains(options, "string"),
+	}
+	if !jf.tagged {
+		jf.name = field.Name
+	}
+
+	return jf, true
+}
+
+// generateJSONSchema generates the JSON Schema describing how values of type t are encoded in JSON.
+// Fields are named after their json tag; fields that are pointers, tagged omitempty or with a default
+// value are optional, while unexported fields and fields tagged "-" are skipped. Properties not
+// matching a field are not allowed.
+// Fields can be annotated with a jsonschema (or mcp) tag, see applySchemaTag.
+func (m *MCPServer) generateJSONSchema(t reflect.Type) (map[string]any, error) {
+	return schemaOf(t, map[reflect.Type]bool{})
+}
+
+// schemaOf returns the schema of t. visiting holds the struct types being generated, to stop on recursive types.
+func schemaOf(t reflect.Type, visiting map[reflect.Type]bool) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Slice, reflect.Array:
+		// []byte is encoded as a base64 string
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		values, err := schemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]any{"type": "object"}, nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		props := map[string]any{}
+		required := []string{}
+		if err := addStructFields(t, props, &required, false, visiting); err != nil {
+			return nil, err
+		}
+
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema, nil
+	default:
+		// interfaces accept any value
+		return map[string]any{}, nil
+	}
+}
+
+// addStructFields adds the schemas of the fields of t to props, inlining embedded structs as encoding/json does.
+// When optional is set, t is embedded through a pointer, which may be nil, so none of its fields is required.
+func addStructFields(t reflect.Type, props map[string]any, required *[]string, optional bool, visiting map[reflect.Type]bool) error {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		jf, ok := parseJSONField(field)
+		if !ok {
+			continue
+		}
+
+		fieldType := field.Type
+		if field.Anonymous && !jf.tagged {
+			embeddedOptional := optional
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+				embeddedOptional = true
+			}
+			if fieldType.Kind() == reflect.Struct {
+				if err := addStructFields(fieldType, props, required, embeddedOptional, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+		}
+
+		schema, err := schemaOf(fieldType, visiting)
+		if err != nil {
+			return err
+		}
+		if jf.asString {
+			schema = map[string]any{"type": "string"}
+		}
+
+		if err := applySchemaTag(schema, schemaTag(field)); err != nil {
+			return fmt.Errorf("%w: field %s: %v", ErrInvalidSchemaTag, field.Name, err)
+		}
+		props[jf.name] = schema
+
+		_, hasDefault := schema["default"]
+		if !optional && !jf.omitempty && !hasDefault && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, jf.name)
+		}
+	}
+
+	return nil
+}
+
+// schemaTag returns the schema annotations of field, taken from the jsonschema tag or, when missing, from the mcp tag.
+func schemaTag(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("jsonschema"); ok {
+		return tag
+	}
+	return field.Tag.Get("mcp")
+}
+
+// applySchemaTag applies to schema the comma separated annotations of tag (commas in values are escaped as "\,"):
+//
+//	title=..., description=...               documentation
+//	enum=...                                 an allowed value, repeated for each value
+//	default=...                              the default value, making the field optional
+//	example=...                              an example value, repeated for each example
+//	minimum=, maximum=, exclusiveMinimum=,
+//	exclusiveMaximum=, multipleOf=           numeric bounds
+//	minLength=, maxLength=, pattern=, format= string constraints
+//	minItems=, maxItems=, uniqueItems        array constraints
+//
+// Values are parsed according to the type of the schema; enum values of arrays apply to their items.
+func applySchemaTag(schema map[string]any, tag string) error {
+	if tag == "" {
+		return nil
+	}
+
+	for _, annotation := range splitSchemaTag(tag) {
+		key, value, hasValue := strings.Cut(annotation, "=")
+		key = strings.TrimSpace(key)
+
+		switch key {
+		case "":
+			continue
+		case "title", "description", "format":
+			schema[key] = value
+		case "pattern":
+			if _, err := regexp.Compile(value); err != nil {
+				return err
+			}
+			schema[key] = value
+		case "enum":
+			target := schema
+			if items, ok := schema["items"].(map[string]any); ok && schema["type"] == "array" {
+				target = items
+			}
+			v, err := parseSchemaValue(target, value)
+			if err != nil {
+				return err
+			}
+			enum, _ := target["enum"].([]any)
+			target["enum"] = append(enum, v)
+		case "default":
+			v, err := parseSchemaValue(schema, value)
+			if err != nil {
+				return err
+			}
+			schema[key] = v
+		case "example":
+			v, err := parseSchemaValue(schema, value)
+			if err != nil {
+				return err
+			}
+			examples, _ := schema["examples"].([]any)
+			schema["examples"] = append(examples, v)
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return err
+			}
+			schema[key] = v
+		case "minLength", "maxLength", "minItems", "maxItems":
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			schema[key] = v
+		case "uniqueItems":
+			v := true
+			if hasValue {
+				var err error
+				if v, err = strconv.ParseBool(value); err != nil {
+					return err
+				}
+			}
+			schema[key] = v
+		default:
+			return fmt.Errorf("unknown annotation %q", key)
+		}
+	}
+
+	return nil
+}
+
+// splitSchemaTag splits tag on the commas not escaped by a backslash.
+func splitSchemaTag(tag string) []string {
+	var parts []string
+	var part strings.Builder
+
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			part.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(tag[i])
+		}
+	}
+
+	return append(parts, part.String())
+}
+
+// parseSchemaValue parses value according to the type of schema.
+func parseSchemaValue(schema map[string]any, value string) (any, error) {
+	switch schema["type"] {
+	case "string":
+		return value, nil
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "boolean":
+		return strconv.ParseBool(value)
+	default:
+		var v any
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+// applyDefaults returns a copy of args where the properties missing from args are set to their
+// default value in schema, recursing into nested objects.
+func applyDefaults(schema map[string]any, args map[string]any) map[string]any {
+	result := maps.Clone(args)
+	if result == nil {
+		result = map[string]any{}
+	}
+
+	props, _ := schema["properties"].(map[string]any)
+	for name, p := range props {
+		prop, _ := p.(map[string]any)
+
+		value, present := result[name]
+		if !present {
+			if def, ok := prop["default"]; ok {
+				result[name] = def
+			}
+			continue
+		}
+
+		if nested, ok := value.(map[string]any); ok && prop["type"] == "object" {
+			result[name] = applyDefaults(prop, nested)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,218 @@
+package gomcp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+type schemaTestAddress struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type schemaTestBase struct {
+	ID int64 `json:"id"`
+}
+
+// schemaTestAudit is embedded by value in a struct embedded through a pointer.
+type schemaTestAudit struct {
+	Version int `json:"version"`
+}
+
+type schemaTestOptional struct {
+	schemaTestAudit
+	Note string `json:"note"`
+}
+
+type schemaTestNode struct {
+	Value    int               `json:"value"`
+	Children []*schemaTestNode `json:"children,omitempty"`
+}
+
+type schemaTestArgs struct {
+	schemaTestBase
+	*schemaTestOptional
+	Name     string               `json:"name"`
+	Age      int                  `json:"age"`
+	Score    float64              `json:"score"`
+	Active   bool                 `json:"active"`
+	Nickname *string              `json:"nickname"`
+	Tags     []string             `json:"tags,omitempty"`
+	Address  schemaTestAddress    `json:"address"`
+	Labels   map[string]int       `json:"labels,omitzero"`
+	Data     []byte               `json:"data,omitempty"`
+	Created  time.Time            `json:"created"`
+	Count    int                  `json:"count,string"`
+	Extra    any                  `json:"extra,omitempty"`
+	Tree     schemaTestNode       `json:"tree"`
+	Untagged string               //
+	Ignored  string               `json:"-"`
+	private  string               //
+	Nested   [2]schemaTestAddress `json:"nested,omitempty"`
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	address := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"street": map[string]any{"type": "string"},
+			"zip":    map[string]any{"type": "string"},
+		},
+		"required": []string{"street"},
+	}
+
+	expected := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"id":       map[string]any{"type": "integer"},
+			"version":  map[string]any{"type": "integer"},
+			"note":     map[string]any{"type": "string"},
+			"name":     map[string]any{"type": "string"},
+			"age":      map[string]any{"type": "integer"},
+			"score":    map[string]any{"type": "number"},
+			"active":   map[string]any{"type": "boolean"},
+			"nickname": map[string]any{"type": "string"},
+			"tags":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"address":  address,
+			"labels":   map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "integer"}},
+			"data":     map[string]any{"type": "string", "contentEncoding": "base64"},
+			"created":  map[string]any{"type": "string", "format": "date-time"},
+			"count":    map[string]any{"type": "string"},
+			"extra":    map[string]any{},
+			"tree": map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]any{
+					"value":    map[string]any{"type": "integer"},
+					"children": map[string]any{"type": "array", "items": map[string]any{"type": "object"}},
+				},
+				"required": []string{"value"},
+			},
+			"Untagged": map[string]any{"type": "string"},
+			"nested":   map[string]any{"type": "array", "items": address},
+		},
+		"required": []string{"id", "name", "age", "score", "active", "address", "created", "count", "tree", "Untagged"},
+	}
+
+	actual, err := mcpserver.generateJSONSchema(reflect.TypeOf(schemaTestArgs{}))
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+
+	empty, _ := mcpserver.generateJSONSchema(reflect.TypeOf(struct{}{}))
+	if !reflect.DeepEqual(empty, map[string]any{"type": "object", "properties": map[string]any{}, "additionalProperties": false}) {
+		t.Errorf("Expected empty object schema but got %v", empty)
+	}
+}
+
+type schemaTestAnnotatedArgs struct {
+	Path    string   `json:"path" jsonschema:"description=The path to list\\, relative to the root,minLength=1,pattern=^[^~]+$,example=./docs"`
+	Mode    string   `json:"mode" jsonschema:"enum=short,enum=long,default=short"`
+	Depth   int      `json:"depth" mcp:"description=Recursion depth,minimum=0,maximum=10,default=1"`
+	Ratio   float64  `json:"ratio,omitempty" jsonschema:"exclusiveMinimum=0,exclusiveMaximum=1,multipleOf=0.1"`
+	Email   string   `json:"email,omitempty" jsonschema:"format=email,title=Email"`
+	Filters []string `json:"filters,omitempty" jsonschema:"enum=dirs,enum=files,minItems=1,maxItems=2,uniqueItems"`
+	Hidden  bool     `json:"hidden" jsonschema:"default=false"`
+}
+
+func TestGenerateJSONSchemaWithAnnotations(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	expected := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"path":  map[string]any{"type": "string", "description": "The path to list, relative to the root", "minLength": 1, "pattern": "^[^~]+$", "examples": []any{"./docs"}},
+			"mode":  map[string]any{"type": "string", "enum": []any{"short", "long"}, "default": "short"},
+			"depth": map[string]any{"type": "integer", "description": "Recursion depth", "minimum": 0.0, "maximum": 10.0, "default": int64(1)},
+			"ratio": map[string]any{"type": "number", "exclusiveMinimum": 0.0, "exclusiveMaximum": 1.0, "multipleOf": 0.1},
+			"email": map[string]any{"type": "string", "format": "email", "title": "Email"},
+			"filters": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string", "enum": []any{"dirs", "files"}},
+				"minItems":    1,
+				"maxItems":    2,
+				"uniqueItems": true,
+			},
+			"hidden": map[string]any{"type": "boolean", "default": false},
+		},
+		"required": []string{"path"},
+	}
+
+	actual, err := mcpserver.generateJSONSchema(reflect.TypeOf(schemaTestAnnotatedArgs{}))
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v but got %v", expected, actual)
+	}
+}
+
+func TestGenerateJSONSchemaWithInvalidAnnotations(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	table := []any{
+		struct {
+			A int `jsonschema:"minimum=abc"`
+		}{},
+		struct {
+			A int `jsonschema:"default=one"`
+		}{},
+		struct {
+			A string `jsonschema:"pattern=[a-"`
+		}{},
+		struct {
+			A string `jsonschema:"unknown=1"`
+		}{},
+	}
+
+	for _, test := range table {
+		_, err := mcpserver.generateJSONSchema(reflect.TypeOf(test))
+		if !errors.Is(err, ErrInvalidSchemaTag) {
+			t.Errorf("Expected %v but got %v", ErrInvalidSchemaTag, err)
+		}
+	}
+}
+
+func TestAddToolFuncAppliesDefaults(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	type Args struct {
+		Path  string `json:"path" jsonschema:"default=."`
+		Depth int    `json:"depth" jsonschema:"default=2"`
+	}
+
+	var received Args
+	err := mcpserver.AddToolFunc("ls", "ls", func(args Args) (*types.ToolResult, error) {
+		received = args
+		return types.NewToolResult(nil), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+
+	mcpserver.tools["ls"].Run(map[string]any{"depth": 5})
+	if expected := (Args{Path: ".", Depth: 5}); received != expected {
+		t.Errorf("Expected %v but got %v", expected, received)
+	}
+
+	mcpserver.tools["ls"].Run(nil)
+	if expected := (Args{Path: ".", Depth: 2}); received != expected {
+		t.Errorf("Expected %v but got %v", expected, received)
+	}
+}
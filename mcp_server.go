@@ -1,13 +1,20 @@
 package gomcp
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"reflect"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/mcpunzo/gomcp/internal/schema_validator"
 	"github.com/mcpunzo/gomcp/internal/type_converter"
+	"github.com/mcpunzo/gomcp/internal/uri_template"
 	"github.com/mcpunzo/gomcp/types"
 )
 
@@ -29,28 +36,79 @@ const (
 	CallTool      = "tools/call"
 	ListResources = "resources/list"
 	ReadResource  = "resources/read"
+	ListPrompts   = "prompts/list"
+	GetPrompt     = "prompts/get"
+
+	ListResourceTemplates = "resources/templates/list"
+)
+
+const (
+	Initialized = "notifications/initialized"
+	Cancelled   = "notifications/cancelled"
+	Progress    = "notifications/progress"
+	Exit        = "exit"
 )
 
 const ShutdownMessage = "MCP Session terminated"
 
+// SupportedProtocolVersions lists the MCP protocol versions supported by default.
+var SupportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
 var (
 	ErrHandlerNotFunction  = errors.New("handler must be a function")
 	ErrHandlerWrongArgs    = errors.New("handler must accept exactly 1 argument")
 	ErrHandlerWrongReturns = errors.New("handler must return exactly 2 values (*types.ToolResult, error)")
 	ErrHandlerArgNotStruct = errors.New("handler argument must be a struct")
+
+	ErrStructuredHandlerWrongReturns = errors.New("structured handler must return exactly 2 values (T, error)")
+	ErrHandlerOutputNotStruct        = errors.New("handler output must be a struct")
+	ErrNilStructuredOutput           = errors.New("structured handler returned a nil output")
+
+	ErrPromptHandlerWrongReturns = errors.New("prompt handler must return exactly 2 values ([]types.PromptMessage, error)")
+	ErrPromptArgNotString        = errors.New("prompt argument fields must be strings")
+
+	ErrNoTransport = errors.New("no transport defined for MCP Server")
 )
 
 type MCPServer struct {
-	name      string
-	version   string
-	tools     map[string]*types.Tool
-	resources map[string]*types.Resource
-	transport Transport
+	name              string
+	version           string
+	tools             map[string]*types.Tool
+	resources         map[string]*types.Resource
+	resourceTemplates []*resourceTemplate
+	prompts           map[string]*types.Prompt
+	notifications     map[string]types.NotificationHandler
+	batchConcurrency  int
+	protocolVersions  []string
+	instructions      string
+	debug             bool
+	progressInterval  time.Duration
+	features          map[Feature]bool
+	experimental      map[string]any
+	session           *Session
+	transport         Transport
+}
+
+// resourceTemplate pairs a registered ResourceTemplate with its parsed URI template.
+type resourceTemplate struct {
+	template *types.ResourceTemplate
+	matcher  *uri_template.URITemplate
 }
 
 // New creates a new MCPServer instance with the given name and version.
 func New(name, version string) *MCPServer {
-	return &MCPServer{name, version, make(map[string]*types.Tool), make(map[string]*types.Resource), nil}
+	return &MCPServer{
+		name:             name,
+		version:          version,
+		tools:            make(map[string]*types.Tool),
+		resources:        make(map[string]*types.Resource),
+		prompts:          make(map[string]*types.Prompt),
+		notifications:    make(map[string]types.NotificationHandler),
+		protocolVersions: slices.Clone(SupportedProtocolVersions),
+		progressInterval: DefaultProgressInterval,
+		features:         make(map[Feature]bool),
+		session:          newSession(),
+	}
 }
 
 // WithTransport sets the transport for the MCPServer.
@@ -60,34 +118,185 @@ func (m *MCPServer) WithTransport(transport Transport) *MCPServer {
 	return m
 }
 
-// Start starts the MCPServer using the configured transport.
-func (m *MCPServer) Run() {
+// WithBatchConcurrency sets the maximum number of requests of a JSON-RPC batch processed concurrently.
+// By default, batches are processed sequentially.
+func (m *MCPServer) WithBatchConcurrency(limit int) *MCPServer {
+	m.batchConcurrency = limit
+	return m
+}
+
+// WithProtocolVersions sets the MCP protocol versions supported by the MCPServer.
+// Versions are dates in the YYYY-MM-DD format, as defined by the MCP specification.
+func (m *MCPServer) WithProtocolVersions(versions ...string) *MCPServer {
+	m.protocolVersions = versions
+	return m
+}
+
+// WithInstructions sets the instructions returned to the client on initialize,
+// describing how to use the server and its features.
+func (m *MCPServer) WithInstructions(instructions string) *MCPServer {
+	m.instructions = instructions
+	return m
+}
+
+// WithDebug enables the debug mode, where the server double checks what the handlers return,
+// e.g. the structured content of the tools against their output schema.
+func (m *MCPServer) WithDebug(debug bool) *MCPServer {
+	m.debug = debug
+	return m
+}
+
+// WithProgressInterval sets the minimum interval between two progress notifications of a request,
+// DefaultProgressInterval by default. Progress reported more often is dropped.
+func (m *MCPServer) WithProgressInterval(interval time.Duration) *MCPServer {
+	m.progressInterval = interval
+	return m
+}
+
+// Session returns the default session of the MCPServer, used by Handle and HandleRequest.
+func (m *MCPServer) Session() *Session {
+	return m.session
+}
+
+// NewSession creates a new session, for the transports serving several clients, each one with its own
+// session. The messages of the session are handled with HandleContext.
+func (m *MCPServer) NewSession() *Session {
+	return newSession()
+}
+
+// Run starts the MCPServer using the configured transport, returning when the transport stops.
+// Cancelling ctx stops the transport at once, cancelling the requests in progress, while Shutdown
+// stops it gracefully. It returns ErrNoTransport when no transport is configured, or the error of the transport.
+func (m *MCPServer) Run(ctx context.Context) error {
+	if m.transport == nil {
+		return ErrNoTransport
+	}
+
 	log.Println("Starting MCP Server...")
+	return m.transport.Start(ctx)
+}
+
+// Shutdown stops the transport gracefully: no new request is accepted and the requests in progress
+// are drained. When ctx is done before they complete, they are cancelled and ctx.Err() is returned.
+func (m *MCPServer) Shutdown(ctx context.Context) error {
 	if m.transport == nil {
-		log.Fatal("No transport defined for MCP Server")
+		return ErrNoTransport
 	}
 
-	m.transport.Start()
+	log.Println("Stopping MCP Server...")
+	return m.transport.Stop(ctx)
 }
 
-// Handle processes a raw JSON-RPC request string and returns the JSON-RPC response string.
+// Handle processes a raw JSON-RPC request string for the default session and returns the JSON-RPC response string.
+// Notifications are not answered, so an empty string is returned for them.
+// A JSON array is processed as a batch and answered with the array of the responses, in order.
 func (m *MCPServer) Handle(request string) (string, error) {
+	return m.HandleContext(context.Background(), m.session, request)
+}
+
+// HandleContext processes a raw JSON-RPC request string for the given session, like Handle.
+// The requests are cancelled when ctx is done, and the messages sent by the server to the client while
+// handling them go through the sender of ctx (see ContextWithSender), or the sender of the session.
+func (m *MCPServer) HandleContext(ctx context.Context, session *Session, request string) (string, error) {
+	data := bytes.TrimSpace([]byte(request))
+	if len(data) > 0 && data[0] == '[' {
+		return m.handleBatch(ctx, session, data), nil
+	}
+
+	response := m.handleMessage(ctx, session, data)
+	if response == nil {
+		return "", nil
+	}
+
+	return string(m.marshalResponse(response)), nil
+}
+
+// handleBatch processes a batch of JSON-RPC messages, concurrently when batch concurrency is enabled.
+func (m *MCPServer) handleBatch(ctx context.Context, session *Session, data []byte) string {
+	var messages []json.RawMessage
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return string(m.marshalResponse(m.handleError(types.NullId(), "Parse error", ErrParse, err.Error())))
+	}
+
+	if len(messages) == 0 {
+		return string(m.marshalResponse(m.handleError(types.NullId(), "Invalid Request", ErrInvalidRequest, "empty batch")))
+	}
+
+	responses := make([]*types.JSONRPCResponse, len(messages))
+	if m.batchConcurrency <= 1 {
+		for i, message := range messages {
+			responses[i] = m.handleMessage(ctx, session, message)
+		}
+	} else {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, m.batchConcurrency)
+		for i, message := range messages {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				responses[i] = m.handleMessage(ctx, session, message)
+			}()
+		}
+		wg.Wait()
+	}
+
+	encoded := []json.RawMessage{}
+	for _, response := range responses {
+		if response != nil {
+			encoded = append(encoded, m.marshalResponse(response))
+		}
+	}
+
+	// a batch made only of notifications is not answered
+	if len(encoded) == 0 {
+		return ""
+	}
+
+	respBytes, _ := json.Marshal(encoded)
+	return string(respBytes)
+}
+
+// handleMessage decodes and handles a single JSON-RPC message.
+func (m *MCPServer) handleMessage(ctx context.Context, session *Session, data []byte) *types.JSONRPCResponse {
 	var req types.JSONRPCRequest
 
-	if err := json.Unmarshal([]byte(request), &req); err != nil {
-		response := m.handleError("", "Parse error", ErrParse, err.Error())
-		respBytes, _ := json.Marshal(response)
-		return string(respBytes), nil
+	if err := json.Unmarshal(data, &req); err != nil {
+		if json.Valid(data) {
+			return m.handleError(types.NullId(), "Invalid Request", ErrInvalidRequest, err.Error())
+		}
+		return m.handleError(types.NullId(), "Parse error", ErrParse, err.Error())
 	}
 
-	response := m.HandleRequest(&req)
+	// a message without method is the response of the client to a request of the server,
+	// recognized by its result or error member, even when null
+	if req.Method == "" && !req.Id.IsZero() {
+		var members struct {
+			Result json.RawMessage `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		}
+		var response types.JSONRPCResponse
+		if json.Unmarshal(data, &members) == nil && (members.Result != nil || members.Error != nil) &&
+			json.Unmarshal(data, &response) == nil {
+			if !session.handleResponse(&response) {
+				log.Printf("Ignoring response to unknown request %s", response.Id)
+			}
+			return nil
+		}
+	}
+
+	return m.handleRequest(ctx, session, &req)
+}
+
+func (m *MCPServer) marshalResponse(response *types.JSONRPCResponse) []byte {
 	respBytes, err := json.Marshal(response)
 	if err != nil {
-		response = m.handleError(req.Id, "Generic Server Error", ErrServerGeneric, err.Error())
+		response = m.handleError(response.Id, "Generic Server Error", ErrServerGeneric, err.Error())
 		respBytes, _ = json.Marshal(response)
 	}
 
-	return string(respBytes), nil
+	return respBytes
 }
 
 // AddTool adds a tool to the MCPServer.
@@ -95,11 +304,205 @@ func (m *MCPServer) AddTool(tool *types.Tool) {
 	m.tools[tool.Name] = tool
 }
 
+// AddToolFunc adds a tool whose InputSchema is generated from the struct accepted by handler.
+// The handler must have the signature func(T) (*types.ToolResult, error), or
+// func(context.Context, T) (*types.ToolResult, error) to receive the context of the request.
 func (m *MCPServer) AddToolFunc(name, description string, handler any) error {
+	argType, err := checkToolHandler(handler, ErrHandlerWrongReturns)
+	if err != nil {
+		return err
+	}
+
+	// check the output type
+	if reflect.TypeOf(handler).Out(0) != reflect.TypeOf((*types.ToolResult)(nil)) {
+		return ErrHandlerWrongReturns
+	}
+
+	// Generate the InputSchema from the struct
+	schema, err := m.generateJSONSchema(argType)
+	if err != nil {
+		return err
+	}
+
+	// Create a wrapper to convert map[string]interface{} -> struct
+	wrappedHandler := func(ctx context.Context, args map[string]interface{}) (*types.ToolResult, error) {
+		output, err := callToolHandler(ctx, handler, argType, schema, args)
+		if err != nil {
+			return nil, err
+		}
+
+		var result *types.ToolResult
+		if !output.IsNil() {
+			result = output.Interface().(*types.ToolResult)
+		}
+
+		return result, nil
+	}
+
+	m.AddTool(types.NewContextTool(name, description, schema, wrappedHandler))
+
+	return nil
+}
+
+// AddStructuredToolFunc adds a tool whose handler returns a typed value instead of a *types.ToolResult.
+// The handler must have the signature func(T) (O, error) or func(context.Context, T) (O, error), where
+// T and O are structs (O can also be a pointer to a struct). The OutputSchema of the tool is generated from O, and the returned value is sent
+// as the structuredContent of the result, together with its JSON serialization as text content.
+func (m *MCPServer) AddStructuredToolFunc(name, description string, handler any) error {
+	argType, err := checkToolHandler(handler, ErrStructuredHandlerWrongReturns)
+	if err != nil {
+		return err
+	}
+
+	// output type must be a struct
+	outType := reflect.TypeOf(handler).Out(0)
+	if outType.Kind() == reflect.Pointer {
+		outType = outType.Elem()
+	}
+	if outType.Kind() != reflect.Struct {
+		return ErrHandlerOutputNotStruct
+	}
+
+	// Generate the InputSchema and the OutputSchema from the structs
+	inputSchema, err := m.generateJSONSchema(argType)
+	if err != nil {
+		return err
+	}
+
+	outputSchema, err := m.generateJSONSchema(outType)
+	if err != nil {
+		return err
+	}
+
+	wrappedHandler := func(ctx context.Context, args map[string]any) (*types.ToolResult, error) {
+		output, err := callToolHandler(ctx, handler, argType, inputSchema, args)
+		if err != nil {
+			return nil, err
+		}
+
+		// a nil output doesn't match the output schema, which requires an object
+		if output.Kind() == reflect.Pointer && output.IsNil() {
+			return nil, NewProtocolError(ErrInternal, fmt.Sprintf("Invalid structured content of tool %v", name), ErrNilStructuredOutput.Error())
+		}
+
+		return types.NewStructuredToolResult(output.Interface())
+	}
+
+	tool := types.NewContextTool(name, description, inputSchema, wrappedHandler)
+	tool.OutputSchema = outputSchema
+	m.AddTool(tool)
+
+	return nil
+}
+
+// checkToolHandler checks that handler is a function accepting exactly one struct argument, optionally
+// preceded by a context.Context, and returning two values, the last one being an error, and returns the
+// type of the struct argument.
+// wrongReturns is the error returned when the handler doesn't return two values.
+func checkToolHandler(handler any, wrongReturns error) (reflect.Type, error) {
 	handlerType := reflect.TypeOf(handler)
 
 	// handler must be a func
-	if handlerType.Kind() != reflect.Func {
+	if handlerType == nil || handlerType.Kind() != reflect.Func {
+		return nil, ErrHandlerNotFunction
+	}
+
+	// check the func signature
+	switch {
+	case handlerType.NumIn() == 1:
+	case handlerType.NumIn() == 2 && handlerType.In(0) == contextType:
+	default:
+		return nil, ErrHandlerWrongArgs
+	}
+
+	if handlerType.NumOut() != 2 {
+		return nil, wrongReturns
+	}
+
+	// input type must be a struct
+	argType := handlerType.In(handlerType.NumIn() - 1)
+	if argType.Kind() != reflect.Struct {
+		return nil, ErrHandlerArgNotStruct
+	}
+
+	if !handlerType.Out(1).Implements(reflect.TypeOf((*error)(nil)).Elem()) {
+		return nil, wrongReturns
+	}
+
+	return argType, nil
+}
+
+// callToolHandler converts args into a new instance of argType, filling the missing ones with their
+// default value in schema, and invokes handler with it, preceded by ctx when the handler accepts it.
+// It returns the first value returned by the handler, or the error returned by it.
+func callToolHandler(ctx context.Context, handler any, argType reflect.Type, schema map[string]any, args map[string]any) (reflect.Value, error) {
+	// Serializza args in JSON, filling the missing ones with their default value
+	jsonData, err := json.Marshal(applyDefaults(schema, args))
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("failed to marshal args: %w", err)
+	}
+
+	// Create a new  input struct instance
+	argValue := reflect.New(argType).Interface()
+
+	// Deserialize JSON into the struct
+	if err := json.Unmarshal(jsonData, argValue); err != nil {
+		return reflect.Value{}, NewProtocolError(ErrInvalidParams, "Invalid parameters", err.Error())
+	}
+
+	// Invoke the original handler
+	in := []reflect.Value{reflect.ValueOf(argValue).Elem()}
+	if reflect.TypeOf(handler).NumIn() == 2 {
+		in = append([]reflect.Value{reflect.ValueOf(ctx)}, in...)
+	}
+	results := reflect.ValueOf(handler).Call(in)
+
+	if !results[1].IsNil() {
+		return reflect.Value{}, results[1].Interface().(error)
+	}
+
+	return results[0], nil
+}
+
+// AddResource adds a resource to the MCPServer.
+func (m *MCPServer) AddResource(resource *types.Resource) {
+	m.resources[resource.URI] = resource
+}
+
+// AddResourceTemplate adds a resource template to the MCPServer.
+// Templates are matched in registration order when no resource has exactly the requested URI;
+// adding a template with an already registered URI template replaces it.
+func (m *MCPServer) AddResourceTemplate(template *types.ResourceTemplate) error {
+	matcher, err := uri_template.Parse(template.URITemplate)
+	if err != nil {
+		return err
+	}
+
+	entry := &resourceTemplate{template, matcher}
+	for i, t := range m.resourceTemplates {
+		if t.template.URITemplate == template.URITemplate {
+			m.resourceTemplates[i] = entry
+			return nil
+		}
+	}
+
+	m.resourceTemplates = append(m.resourceTemplates, entry)
+	return nil
+}
+
+// AddPrompt adds a prompt to the MCPServer.
+func (m *MCPServer) AddPrompt(prompt *types.Prompt) {
+	m.prompts[prompt.Name] = prompt
+}
+
+// AddPromptFunc adds a prompt whose arguments are derived from the struct accepted by handler.
+// The handler must have the signature func(T) ([]types.PromptMessage, error), where T is a struct
+// whose exported fields are all strings.
+func (m *MCPServer) AddPromptFunc(name, description string, handler any) error {
+	handlerType := reflect.TypeOf(handler)
+
+	// handler must be a func
+	if handlerType == nil || handlerType.Kind() != reflect.Func {
 		return ErrHandlerNotFunction
 	}
 
@@ -109,7 +512,7 @@ func (m *MCPServer) AddToolFunc(name, description string, handler any) error {
 	}
 
 	if handlerType.NumOut() != 2 {
-		return ErrHandlerWrongReturns
+		return ErrPromptHandlerWrongReturns
 	}
 
 	// input type must be a struct
@@ -119,60 +522,57 @@ func (m *MCPServer) AddToolFunc(name, description string, handler any) error {
 	}
 
 	// check the output types
-	if handlerType.Out(0) != reflect.TypeOf((*types.ToolResult)(nil)) {
-		return ErrHandlerWrongReturns
+	if handlerType.Out(0) != reflect.TypeOf([]types.PromptMessage(nil)) {
+		return ErrPromptHandlerWrongReturns
 	}
 	if !handlerType.Out(1).Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-		return ErrHandlerWrongReturns
+		return ErrPromptHandlerWrongReturns
 	}
 
-	// Generate the InputSchema from the struct
-	schema := m.generateJSONSchema(argType)
+	// Derive the prompt arguments from the struct
+	arguments, err := m.generatePromptArguments(argType)
+	if err != nil {
+		return err
+	}
 
-	// Create a wrapper to convert map[string]interface{} -> struct
-	wrappedHandler := func(args map[string]interface{}) (*types.ToolResult, error) {
-		// Serializza args in JSON
+	// Create a wrapper to convert map[string]string -> struct
+	wrappedHandler := func(args map[string]string) ([]types.PromptMessage, error) {
 		jsonData, err := json.Marshal(args)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal args: %w", err)
 		}
 
-		// Create a new  input struct instance
 		argValue := reflect.New(argType).Interface()
-
-		// Deserialize JSON into the struct
 		if err := json.Unmarshal(jsonData, argValue); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal args: %w", err)
 		}
 
-		// Invoke the original handler
-		handlerValue := reflect.ValueOf(handler)
-		results := handlerValue.Call([]reflect.Value{
+		results := reflect.ValueOf(handler).Call([]reflect.Value{
 			reflect.ValueOf(argValue).Elem(),
 		})
 
-		// Extract results
-		var result *types.ToolResult
+		var messages []types.PromptMessage
 		var errResult error
 
 		if !results[0].IsNil() {
-			result = results[0].Interface().(*types.ToolResult)
+			messages = results[0].Interface().([]types.PromptMessage)
 		}
 		if !results[1].IsNil() {
 			errResult = results[1].Interface().(error)
 		}
 
-		return result, errResult
+		return messages, errResult
 	}
 
-	m.AddTool(types.NewTool(name, description, schema, wrappedHandler))
+	m.AddPrompt(types.NewPrompt(name, description, arguments, wrappedHandler))
 
 	return nil
 }
 
-// AddResource adds a resource to the MCPServer.
-func (m *MCPServer) AddResource(resource *types.Resource) {
-	m.resources[resource.URI] = resource
+// OnNotification registers the handler invoked when a notification with the given method is received.
+// Built-in notifications, such as notifications/initialized, are processed by the server before the handler is invoked.
+func (m *MCPServer) OnNotification(method string, handler types.NotificationHandler) {
+	m.notifications[method] = handler
 }
 
 // Tools returns a list of all registered tools.
@@ -185,37 +585,151 @@ func (m *MCPServer) Resources() []types.Resource {
 	return type_converter.MapValueToArray(m.resources)
 }
 
+// ResourceTemplates returns a list of all registered resource templates.
+func (m *MCPServer) ResourceTemplates() []types.ResourceTemplate {
+	templates := make([]types.ResourceTemplate, 0, len(m.resourceTemplates))
+	for _, t := range m.resourceTemplates {
+		templates = append(templates, *t.template)
+	}
+
+	return templates
+}
+
+// Prompts returns a list of all registered prompts.
+func (m *MCPServer) Prompts() []types.Prompt {
+	return type_converter.MapValueToArray(m.prompts)
+}
+
 // HandleRequest handles an incoming JSON-RPC request and returns the appropriate response.
+// Notifications are dispatched to the registered notification handlers and nil is returned.
 func (m *MCPServer) HandleRequest(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	return m.handleRequest(context.Background(), m.session, req)
+}
+
+func (m *MCPServer) handleRequest(parent context.Context, session *Session, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	if req.IsNotification() {
+		m.handleNotification(session, req)
+		return nil
+	}
+
 	log.Printf("Handling request: %s", req.Method)
 
+	if err := session.checkRequest(req.Method); err != nil {
+		return m.handleError(req.Id, "Invalid Request", ErrInvalidRequest, err.Error())
+	}
+
+	ctx, cancel := m.newRequestContext(parent, session, req)
+	defer cancel()
+
+	response := m.dispatch(ctx, req)
+
+	// the client is no longer interested in the response of a cancelled request
+	if reason, cancelled := CancellationReasonFromContext(ctx); cancelled {
+		log.Printf("Request %s cancelled by the client: %q", req.Id, reason)
+		return nil
+	}
+
+	return response
+}
+
+// dispatch invokes the handler of the method of req.
+func (m *MCPServer) dispatch(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
 	switch req.Method {
 	case Initialize:
-		return m.handleInitialize(req)
+		return m.handleInitialize(SessionFromContext(ctx), req)
 	case Shutdown:
-		return m.handleShutdown(req)
+		return m.handleShutdown(SessionFromContext(ctx), req)
 	case ListTools:
 		return m.handleListTools(req)
 	case CallTool:
-		return m.handleCallTool(req)
+		return m.handleCallTool(ctx, req)
 	case ListResources:
 		return m.handleListResources(req)
 	case ReadResource:
-		return m.handleReadResource(req)
+		return m.handleReadResource(ctx, req)
+	case ListResourceTemplates:
+		return m.handleListResourceTemplates(req)
+	case ListPrompts:
+		return m.handleListPrompts(req)
+	case GetPrompt:
+		return m.handleGetPrompt(req)
 	default:
 		return m.handleError(req.Id, "Method Not Found", ErrMethodNotFound, req.Method)
 	}
 }
 
-func (m *MCPServer) handleInitialize(req *types.JSONRPCRequest) *types.JSONRPCResponse {
-	return types.NewJSONRPCResponse(req.Id, types.NewInitializeResult(m.name, m.version, len(m.tools) > 0, len(m.resources) > 0), nil)
+func (m *MCPServer) handleNotification(session *Session, req *types.JSONRPCRequest) {
+	log.Printf("Handling notification: %s", req.Method)
+
+	switch req.Method {
+	case Initialized:
+		if !session.transition(SessionInitializing, SessionReady) {
+			log.Printf("Ignoring %s in state %v", req.Method, session.State())
+			break
+		}
+		session.prefetchRoots()
+	case RootsListChanged:
+		session.invalidateRoots()
+		session.prefetchRoots()
+	case Cancelled:
+		paramsBytes, _ := json.Marshal(req.Params)
+		var params types.CancelledParams
+		if err := json.Unmarshal(paramsBytes, &params); err != nil {
+			log.Printf("Ignoring %s with invalid params: %v", req.Method, err)
+			break
+		}
+
+		if !session.cancelRequest(params.RequestId.String(), &CancelledError{Reason: params.Reason}) {
+			log.Printf("Ignoring %s for request %s not in progress", req.Method, params.RequestId)
+		}
+	case Exit:
+		session.close()
+	}
+
+	if handler, exists := m.notifications[req.Method]; exists {
+		handler(req.Params)
+	}
+}
+
+func (m *MCPServer) handleInitialize(session *Session, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	paramsBytes, _ := json.Marshal(req.Params)
+	var params types.InitializeParams
+	if err := json.Unmarshal(paramsBytes, &params); err != nil {
+		return m.handleError(req.Id, "Invalid parameters", ErrInvalidParams, req.Method)
+	}
+
+	protocolVersion, ok := m.negotiateProtocolVersion(params.ProtocolVersion)
+	if !ok {
+		return m.handleError(req.Id, "Unsupported protocol version", ErrInvalidParams, types.ProtocolVersionErrorData{Supported: m.protocolVersions, Requested: params.ProtocolVersion})
+	}
+
+	session.initialize(protocolVersion, &params)
+
+	return types.NewJSONRPCResponse(req.Id, types.NewInitializeResult(protocolVersion, m.name, m.version, m.capabilities(), m.instructions), nil)
+}
+
+// negotiateProtocolVersion returns the highest protocol version supported by the server
+// that is not newer than the one requested by the client. When the client does not send
+// a version, the latest version supported by the server is used.
+func (m *MCPServer) negotiateProtocolVersion(requested string) (string, bool) {
+	versions := slices.Sorted(slices.Values(m.protocolVersions))
+	slices.Reverse(versions)
+
+	for _, version := range versions {
+		if requested == "" || version <= requested {
+			return version, true
+		}
+	}
+
+	return "", false
 }
 
-func (m *MCPServer) handleError(id, message string, code int, data any) *types.JSONRPCResponse {
+func (m *MCPServer) handleError(id types.RequestId, message string, code int, data any) *types.JSONRPCResponse {
 	return types.NewJSONRPCResponse(id, nil, types.NewJSONRPCErrorObj(code, message, data))
 }
 
-func (m *MCPServer) handleShutdown(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+func (m *MCPServer) handleShutdown(session *Session, req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	session.transition(SessionReady, SessionShuttingDown)
 	return types.NewJSONRPCResponse(req.Id, types.NewShutdownResult(ShutdownMessage), nil)
 }
 
@@ -227,7 +741,7 @@ func (m *MCPServer) handleListResources(req *types.JSONRPCRequest) *types.JSONRP
 	return types.NewJSONRPCResponse(req.Id, types.NewListResourcesResult(m.Resources()), nil)
 }
 
-func (m *MCPServer) handleCallTool(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+func (m *MCPServer) handleCallTool(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
 	paramsBytes, _ := json.Marshal(req.Params)
 	var params types.CallToolParams
 	if err := json.Unmarshal(paramsBytes, &params); err != nil {
@@ -243,15 +757,56 @@ func (m *MCPServer) handleCallTool(req *types.JSONRPCRequest) *types.JSONRPCResp
 		return m.handleError(req.Id, "Unknown Tool", ErrMethodNotFound, req.Method)
 	}
 
-	res, err := tool.Run(params.Arguments)
+	if tool.InputSchema != nil {
+		arguments := params.Arguments
+		if arguments == nil {
+			arguments = map[string]any{}
+		}
+
+		violations, err := schema_validator.Validate(tool.InputSchema, arguments)
+		if err != nil {
+			return m.handleError(req.Id, fmt.Sprintf("Invalid input schema of tool %v", tool.Name), ErrInternal, err.Error())
+		}
+
+		if len(violations) > 0 {
+			return m.handleError(req.Id, "Invalid parameters", ErrInvalidParams, violations)
+		}
+	}
+
+	// tool failures are reported to the model as results, unless the handler asks for a JSON-RPC error
+	var res *types.ToolResult
+	var err error
+	if tool.RunContext != nil {
+		res, err = tool.RunContext(ctx, params.Arguments)
+	} else {
+		res, err = tool.Run(params.Arguments)
+	}
 	if err != nil {
-		return m.handleError(req.Id, fmt.Sprintf("Error executing tool %v", tool.Name), ErrServerGeneric, err.Error())
+		var protocolErr *ProtocolError
+		if errors.As(err, &protocolErr) {
+			return m.handleError(req.Id, protocolErr.Message, protocolErr.Code, protocolErr.Data)
+		}
+
+		return types.NewJSONRPCResponse(req.Id, toolErrorResult(err), nil)
+	}
+
+	// in debug mode, check that the structured content matches the declared output schema
+	if m.debug && tool.OutputSchema != nil && res != nil && !res.IsError {
+		violations, err := schema_validator.Validate(tool.OutputSchema, res.StructuredContent)
+		if err != nil {
+			return m.handleError(req.Id, fmt.Sprintf("Invalid output schema of tool %v", tool.Name), ErrInternal, err.Error())
+		}
+
+		if len(violations) > 0 {
+			log.Printf("Tool %s returned structured content not matching its output schema: %v", tool.Name, violations)
+			return m.handleError(req.Id, fmt.Sprintf("Invalid structured content of tool %v", tool.Name), ErrInternal, violations)
+		}
 	}
 
 	return types.NewJSONRPCResponse(req.Id, res, nil)
 }
 
-func (m *MCPServer) handleReadResource(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+func (m *MCPServer) handleReadResource(ctx context.Context, req *types.JSONRPCRequest) *types.JSONRPCResponse {
 	paramsBytes, _ := json.Marshal(req.Params)
 	var params types.ReadResourceParams
 	if err := json.Unmarshal(paramsBytes, &params); err != nil {
@@ -264,10 +819,16 @@ func (m *MCPServer) handleReadResource(req *types.JSONRPCRequest) *types.JSONRPC
 
 	resource, exists := m.resources[params.URI]
 	if !exists {
-		return m.handleError(req.Id, "Unknown Resource", ErrMethodNotFound, req.Method)
+		return m.handleReadResourceTemplate(ctx, req, params.URI)
 	}
 
-	content, err := resource.Read(params.URI)
+	var content []types.OperationContent
+	var err error
+	if resource.ReadContext != nil {
+		content, err = resource.ReadContext(ctx, params.URI)
+	} else {
+		content, err = resource.Read(params.URI)
+	}
 	if err != nil {
 		return m.handleError(req.Id, fmt.Sprintf("Error reading resource %v", resource.Name), ErrServerGeneric, err.Error())
 	}
@@ -275,22 +836,94 @@ func (m *MCPServer) handleReadResource(req *types.JSONRPCRequest) *types.JSONRPC
 	return types.NewJSONRPCResponse(req.Id, types.NewReadResourceResult(content), nil)
 }
 
-func (m *MCPServer) generateJSONSchema(t reflect.Type) map[string]any {
-	props := map[string]any{}
-	required := []string{}
+func (m *MCPServer) handleListResourceTemplates(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	return types.NewJSONRPCResponse(req.Id, types.NewListResourceTemplatesResult(m.ResourceTemplates()), nil)
+}
+
+// handleReadResourceTemplate reads uri through the first resource template matching it.
+func (m *MCPServer) handleReadResourceTemplate(ctx context.Context, req *types.JSONRPCRequest, uri string) *types.JSONRPCResponse {
+	for _, t := range m.resourceTemplates {
+		vars, ok := t.matcher.Match(uri)
+		if !ok {
+			continue
+		}
+
+		var content []types.OperationContent
+		var err error
+		if t.template.ReadContext != nil {
+			content, err = t.template.ReadContext(ctx, uri, vars)
+		} else {
+			content, err = t.template.Read(uri, vars)
+		}
+		if err != nil {
+			return m.handleError(req.Id, fmt.Sprintf("Error reading resource %v", t.template.Name), ErrServerGeneric, err.Error())
+		}
+
+		return types.NewJSONRPCResponse(req.Id, types.NewReadResourceResult(content), nil)
+	}
+
+	return m.handleError(req.Id, "Unknown Resource", ErrMethodNotFound, req.Method)
+}
+
+func (m *MCPServer) handleListPrompts(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	return types.NewJSONRPCResponse(req.Id, types.NewListPromptsResult(m.Prompts()), nil)
+}
+
+func (m *MCPServer) handleGetPrompt(req *types.JSONRPCRequest) *types.JSONRPCResponse {
+	paramsBytes, _ := json.Marshal(req.Params)
+	var params types.GetPromptParams
+	if err := json.Unmarshal(paramsBytes, &params); err != nil {
+		return m.handleError(req.Id, "Invalid parameters", ErrInvalidParams, req.Method)
+	}
+
+	if params.Name == "" {
+		return m.handleError(req.Id, "Invalid parameters", ErrInvalidParams, req.Method)
+	}
+
+	prompt, exists := m.prompts[params.Name]
+	if !exists {
+		return m.handleError(req.Id, "Unknown Prompt", ErrMethodNotFound, req.Method)
+	}
+
+	for _, arg := range prompt.Arguments {
+		if _, ok := params.Arguments[arg.Name]; arg.Required && !ok {
+			return m.handleError(req.Id, "Missing required argument", ErrInvalidParams, arg.Name)
+		}
+	}
+
+	messages, err := prompt.Get(params.Arguments)
+	if err != nil {
+		return m.handleError(req.Id, fmt.Sprintf("Error getting prompt %v", prompt.Name), ErrServerGeneric, err.Error())
+	}
+
+	return types.NewJSONRPCResponse(req.Id, types.NewGetPromptResult(prompt.Description, messages), nil)
+}
+
+// generatePromptArguments derives the prompt arguments from the exported fields of t.
+// Fields tagged with omitempty are optional, all the others are required; descriptions
+// are taken from the jsonschema (or mcp) tag.
+func (m *MCPServer) generatePromptArguments(t reflect.Type) ([]types.PromptArgument, error) {
+	arguments := []types.PromptArgument{}
 
 	for i := range t.NumField() {
 		field := t.Field(i)
-		name := field.Name
-		props[name] = map[string]any{"type": field.Type.Kind().String()}
-		required = append(required, name)
-	}
+		jf, ok := parseJSONField(field)
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		if field.Type.Kind() != reflect.String {
+			return nil, ErrPromptArgNotString
+		}
+
+		annotations := map[string]any{"type": "string"}
+		if err := applySchemaTag(annotations, schemaTag(field)); err != nil {
+			return nil, fmt.Errorf("%w: field %s: %v", ErrInvalidSchemaTag, field.Name, err)
+		}
+		description, _ := annotations["description"].(string)
 
-	inputSchema := map[string]any{
-		"type":       "object",
-		"properties": props,
-		"required":   required,
+		arguments = append(arguments, *types.NewPromptArgument(jf.name, description, !jf.omitempty))
 	}
 
-	return inputSchema
+	return arguments, nil
 }
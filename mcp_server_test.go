@@ -1,6 +1,7 @@
 package gomcp
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,6 +26,16 @@ func setupTest(tb testing.TB) (*MCPServer, func(tb testing.TB)) {
 	}
 }
 
+// initializeTest brings the session of mcpserver to the ready state.
+func initializeTest(tb testing.TB, mcpserver *MCPServer) {
+	response := mcpserver.HandleRequest(types.NewJSONRPCRequest(types.NewStringId("init"), Initialize, types.NewInitializeParams("2025-06-18", "test", "1.0")))
+	if response.Error != nil {
+		tb.Fatalf("Expected nil but got %v", response.Error)
+	}
+
+	mcpserver.HandleRequest(&types.JSONRPCRequest{JSONRPC: "2.0", Method: Initialized})
+}
+
 func TestMCPServerNew(t *testing.T) {
 	_, teardown := setupTest(t)
 	defer teardown(t)
@@ -33,7 +44,8 @@ func TestMCPServerNew(t *testing.T) {
 type MockTransport struct{}
 
 func (m *MockTransport) SetMCPServer(mcpserver *MCPServer) {}
-func (m *MockTransport) Start()                            {}
+func (m *MockTransport) Start(ctx context.Context) error   { return nil }
+func (m *MockTransport) Stop(ctx context.Context) error    { return nil }
 
 func TestWithTransport(t *testing.T) {
 	mcpserver, teardown := setupTest(t)
@@ -48,6 +60,19 @@ func TestWithTransport(t *testing.T) {
 	}
 }
 
+func TestRunWithoutTransport(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	if err := mcpserver.Run(context.Background()); !errors.Is(err, ErrNoTransport) {
+		t.Errorf("Expected %#v but got %#v", ErrNoTransport, err)
+	}
+
+	if err := mcpserver.Shutdown(context.Background()); !errors.Is(err, ErrNoTransport) {
+		t.Errorf("Expected %#v but got %#v", ErrNoTransport, err)
+	}
+}
+
 func TestHandleRequest(t *testing.T) {
 	mcpserver, teardown := setupTest(t)
 	defer teardown(t)
@@ -57,24 +82,36 @@ func TestHandleRequest(t *testing.T) {
 		expected *types.JSONRPCResponse
 	}{
 		{
-			types.NewJSONRPCRequest("id", Initialize, types.NewInitializeParams("test", "1.0")),
-			types.NewJSONRPCResponse("id", types.NewInitializeResult(mcpserver.name, mcpserver.version, len(mcpserver.tools) > 0, len(mcpserver.resources) > 0), nil),
+			types.NewJSONRPCRequest(types.NewStringId("id"), Initialize, types.NewInitializeParams("2025-06-18", "test", "1.0")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewInitializeResult("2025-06-18", mcpserver.name, mcpserver.version, mcpserver.capabilities(), ""), nil),
+		},
+		{
+			&types.JSONRPCRequest{JSONRPC: "2.0", Method: Initialized},
+			nil,
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), "UnknownMethod", nil),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrMethodNotFound, "Method Not Found", "UnknownMethod")),
 		},
 		{
-			types.NewJSONRPCRequest("id", "UnknownMethod", nil),
-			types.NewJSONRPCResponse("id", nil, types.NewJSONRPCErrorObj(ErrMethodNotFound, "Method Not Found", "UnknownMethod")),
+			types.NewJSONRPCRequest(types.NewStringId("id"), ListTools, nil),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewListToolsResult(mcpserver.Tools()), nil),
 		},
 		{
-			types.NewJSONRPCRequest("id", Shutdown, types.NewShutdownParams()),
-			types.NewJSONRPCResponse("id", types.NewShutdownResult(ShutdownMessage), nil),
+			types.NewJSONRPCRequest(types.NewStringId("id"), ListResources, nil),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewListResourcesResult(mcpserver.Resources()), nil),
 		},
 		{
-			types.NewJSONRPCRequest("id", ListTools, nil),
-			types.NewJSONRPCResponse("id", types.NewListToolsResult(mcpserver.Tools()), nil),
+			types.NewJSONRPCRequest(types.NewStringId("id"), ListResourceTemplates, nil),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewListResourceTemplatesResult(mcpserver.ResourceTemplates()), nil),
 		},
 		{
-			types.NewJSONRPCRequest("id", ListResources, nil),
-			types.NewJSONRPCResponse("id", types.NewListResourcesResult(mcpserver.Resources()), nil),
+			types.NewJSONRPCRequest(types.NewStringId("id"), ListPrompts, nil),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewListPromptsResult(mcpserver.Prompts()), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), Shutdown, types.NewShutdownParams()),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewShutdownResult(ShutdownMessage), nil),
 		},
 	}
 
@@ -86,12 +123,185 @@ func TestHandleRequest(t *testing.T) {
 	}
 }
 
+func TestHandleRequestWithInitialize(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	mcpserver.WithProtocolVersions("2024-11-05", "2025-06-18", "2025-03-26").WithInstructions("use the tools")
+
+	table := []struct {
+		req      *types.JSONRPCRequest
+		expected *types.JSONRPCResponse
+	}{
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), Initialize, types.NewInitializeParams("2025-06-18", "test", "1.0")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewInitializeResult("2025-06-18", mcpserver.name, mcpserver.version, mcpserver.capabilities(), "use the tools"), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), Initialize, types.NewInitializeParams("2025-05-01", "test", "1.0")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewInitializeResult("2025-03-26", mcpserver.name, mcpserver.version, mcpserver.capabilities(), "use the tools"), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), Initialize, types.NewInitializeParams("2099-01-01", "test", "1.0")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewInitializeResult("2025-06-18", mcpserver.name, mcpserver.version, mcpserver.capabilities(), "use the tools"), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), Initialize, types.NewInitializeParams("", "test", "1.0")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewInitializeResult("2025-06-18", mcpserver.name, mcpserver.version, mcpserver.capabilities(), "use the tools"), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), Initialize, types.NewInitializeParams("2024-01-01", "test", "1.0")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrInvalidParams, "Unsupported protocol version",
+				types.ProtocolVersionErrorData{Supported: []string{"2024-11-05", "2025-06-18", "2025-03-26"}, Requested: "2024-01-01"})),
+		},
+	}
+
+	for _, test := range table {
+		mcpserver.session = newSession()
+		actual := mcpserver.HandleRequest(test.req)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %#v but got %#v", test.expected, actual)
+		}
+	}
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	session := mcpserver.Session()
+
+	table := []struct {
+		request          string
+		expectedResponse string
+		expectedState    SessionState
+	}{
+		{
+			`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Invalid Request","data":"session not initialized"}}`,
+			SessionUninitialized,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			``,
+			SessionUninitialized,
+		},
+		{
+			`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{"sampling":{}},"clientInfo":{"name":"client","version":"2.0"}}}`,
+			fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"%v","version":"%v"},"capabilities":{}}}`,
+				mcpserver.name, mcpserver.version),
+			SessionInitializing,
+		},
+		{
+			`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":3,"error":{"code":-32600,"message":"Invalid Request","data":"session not ready: waiting for notifications/initialized"}}`,
+			SessionInitializing,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			``,
+			SessionReady,
+		},
+		{
+			`{"jsonrpc":"2.0","id":4,"method":"initialize","params":{"protocolVersion":"2025-06-18"}}`,
+			`{"jsonrpc":"2.0","id":4,"error":{"code":-32600,"message":"Invalid Request","data":"session already initialized"}}`,
+			SessionReady,
+		},
+		{
+			`{"jsonrpc":"2.0","id":5,"method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":5,"result":{"tools":[]}}`,
+			SessionReady,
+		},
+		{
+			`{"jsonrpc":"2.0","id":6,"method":"shutdown"}`,
+			`{"jsonrpc":"2.0","id":6,"result":{"message":"MCP Session terminated"}}`,
+			SessionShuttingDown,
+		},
+		{
+			`{"jsonrpc":"2.0","id":7,"method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":7,"error":{"code":-32600,"message":"Invalid Request","data":"session is shutting down"}}`,
+			SessionShuttingDown,
+		},
+	}
+
+	for _, test := range table {
+		actualResponse, _ := mcpserver.Handle(test.request)
+		if actualResponse != test.expectedResponse {
+			t.Errorf("Expected %s but got %s", test.expectedResponse, actualResponse)
+		}
+
+		if session.State() != test.expectedState {
+			t.Errorf("Expected state %v but got %v", test.expectedState, session.State())
+		}
+	}
+
+	expectedClientInfo := types.ClientInfo{Name: "client", Version: "2.0"}
+	if session.ClientInfo() != expectedClientInfo {
+		t.Errorf("Expected %v but got %v", expectedClientInfo, session.ClientInfo())
+	}
+
+	if session.ClientCapabilities().Sampling == nil {
+		t.Errorf("Expected sampling capability but got nil")
+	}
+
+	if session.ProtocolVersion() != "2025-06-18" {
+		t.Errorf("Expected 2025-06-18 but got %v", session.ProtocolVersion())
+	}
+
+	select {
+	case <-session.Done():
+		t.Errorf("Expected session not done before exit")
+	default:
+	}
+
+	mcpserver.Handle(`{"jsonrpc":"2.0","method":"exit"}`)
+
+	select {
+	case <-session.Done():
+	default:
+		t.Errorf("Expected session done after exit")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	if !reflect.DeepEqual(mcpserver.capabilities(), types.Capabilities{}) {
+		t.Errorf("Expected no capabilities but got %#v", mcpserver.capabilities())
+	}
+
+	mcpserver.AddTool(types.NewTool("tool", "tool", nil, nil))
+	mcpserver.AddPrompt(types.NewPrompt("prompt", "prompt", nil, nil))
+	mcpserver.WithFeatures(FeatureResourcesSubscribe, FeaturePromptsListChanged, FeatureLogging).
+		WithExperimental("tracing", map[string]any{"enabled": true})
+
+	expected := types.Capabilities{
+		Experimental: map[string]any{"tracing": map[string]any{"enabled": true}},
+		Logging:      &types.LoggingCapability{},
+		Prompts:      &types.PromptsCapability{ListChanged: true},
+		Resources:    &types.ResourcesCapability{Subscribe: true},
+		Tools:        &types.ToolsCapability{},
+	}
+	if !reflect.DeepEqual(mcpserver.capabilities(), expected) {
+		t.Errorf("Expected %#v but got %#v", expected, mcpserver.capabilities())
+	}
+
+	actual, _ := json.Marshal(mcpserver.capabilities())
+	expectedJSON := `{"experimental":{"tracing":{"enabled":true}},"logging":{},"prompts":{"listChanged":true},"resources":{"subscribe":true},"tools":{}}`
+	if string(actual) != expectedJSON {
+		t.Errorf("Expected %s but got %s", expectedJSON, actual)
+	}
+}
+
 func TestHandleRequestWithCallTool(t *testing.T) {
 	mcpserver, teardown := setupTest(t)
 	defer teardown(t)
+	initializeTest(t, mcpserver)
 
 	err := errors.New("worng parameter")
 	content := []types.OperationContent{*types.NewOperationContent("text", "content of file£", "", nil)}
+	missingContent := []types.OperationContent{*types.NewOperationContent("text", "no such file", "", nil)}
 
 	tool := types.NewTool(
 		"read_file",
@@ -111,6 +321,13 @@ func TestHandleRequestWithCallTool(t *testing.T) {
 				return nil, err
 			}
 
+			switch path {
+			case "/denied":
+				return nil, NewProtocolError(ErrAccessDenied, "Access denied", path)
+			case "/missing":
+				return nil, fmt.Errorf("read_file: %w", NewToolError("file not found", missingContent...))
+			}
+
 			return types.NewToolResult(content), nil
 		},
 	)
@@ -122,21 +339,34 @@ func TestHandleRequestWithCallTool(t *testing.T) {
 		expected *types.JSONRPCResponse
 	}{
 		{
-			types.NewJSONRPCRequest("id", CallTool, types.NewCallToolParams("read_file", map[string]any{"path": "/tmp/example.txt"})),
-			types.NewJSONRPCResponse("id", types.NewToolResult(content), nil),
+			types.NewJSONRPCRequest(types.NewStringId("id"), CallTool, types.NewCallToolParams("read_file", map[string]any{"path": "/tmp/example.txt"})),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewToolResult(content), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), CallTool, types.NewCallToolParams("read_file", map[string]any{"wrong_param": "/tmp/example.txt"})),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrInvalidParams, "Invalid parameters",
+				[]types.SchemaViolation{*types.NewSchemaViolation("/path", "required", "is required")})),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), CallTool, types.NewCallToolParams("read_file", map[string]any{"path": ""})),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewToolErrorResult([]types.OperationContent{*types.NewOperationContent("text", err.Error(), "", nil)}), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), CallTool, types.NewCallToolParams("read_file", map[string]any{"path": "/denied"})),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrAccessDenied, "Access denied", "/denied")),
 		},
 		{
-			types.NewJSONRPCRequest("id", CallTool, types.NewCallToolParams("read_file", map[string]any{"wrong_param": "/tmp/example.txt"})),
-			types.NewJSONRPCResponse("id", nil, types.NewJSONRPCErrorObj(ErrServerGeneric, "Error executing tool read_file", err.Error())),
+			types.NewJSONRPCRequest(types.NewStringId("id"), CallTool, types.NewCallToolParams("read_file", map[string]any{"path": "/missing"})),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewToolErrorResult(missingContent), nil),
 		},
 		{
-			types.NewJSONRPCRequest("id", CallTool, types.NewCallToolParams("not_existing_tool", map[string]any{"path": "/tmp/example.txt"})),
-			types.NewJSONRPCResponse("id", nil, types.NewJSONRPCErrorObj(ErrMethodNotFound, "Unknown Tool", CallTool)),
+			types.NewJSONRPCRequest(types.NewStringId("id"), CallTool, types.NewCallToolParams("not_existing_tool", map[string]any{"path": "/tmp/example.txt"})),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrMethodNotFound, "Unknown Tool", CallTool)),
 		},
 
 		{
-			types.NewJSONRPCRequest("id", CallTool, types.NewShutdownParams()),
-			types.NewJSONRPCResponse("id", nil, types.NewJSONRPCErrorObj(ErrInvalidParams, "Invalid parameters", CallTool)),
+			types.NewJSONRPCRequest(types.NewStringId("id"), CallTool, types.NewShutdownParams()),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrInvalidParams, "Invalid parameters", CallTool)),
 		},
 	}
 
@@ -148,9 +378,81 @@ func TestHandleRequestWithCallTool(t *testing.T) {
 	}
 }
 
+func TestHandleRequestWithCallToolValidation(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	type Args struct {
+		Path  string   `json:"path" jsonschema:"minLength=1"`
+		Depth int      `json:"depth,omitempty" jsonschema:"minimum=0,maximum=5"`
+		Sort  string   `json:"sort,omitempty" jsonschema:"enum=name,enum=size"`
+		Tags  []string `json:"tags,omitempty"`
+	}
+
+	called := false
+	mcpserver.AddToolFunc("ls", "ls", func(args Args) (*types.ToolResult, error) {
+		called = true
+		return types.NewToolResult(nil), nil
+	})
+
+	table := []struct {
+		arguments          map[string]any
+		expectedViolations []types.SchemaViolation
+	}{
+		{
+			map[string]any{"path": ".", "depth": 2, "sort": "size", "tags": []string{"a"}},
+			nil,
+		},
+		{
+			nil,
+			[]types.SchemaViolation{*types.NewSchemaViolation("/path", "required", "is required")},
+		},
+		{
+			map[string]any{"path": "", "depth": 7, "sort": "date", "tags": []any{"a", 1}, "recursive": true},
+			[]types.SchemaViolation{
+				*types.NewSchemaViolation("/depth", "maximum", "must be <= 5"),
+				*types.NewSchemaViolation("/path", "minLength", "must be at least 1 characters long"),
+				*types.NewSchemaViolation("/recursive", "additionalProperties", "is not an allowed property"),
+				*types.NewSchemaViolation("/sort", "enum", `must be one of ["name","size"]`),
+				*types.NewSchemaViolation("/tags/1", "type", "must be of type string, got number"),
+			},
+		},
+		{
+			map[string]any{"path": 1, "depth": 1.5},
+			[]types.SchemaViolation{
+				*types.NewSchemaViolation("/depth", "type", "must be of type integer, got number"),
+				*types.NewSchemaViolation("/path", "type", "must be of type string, got number"),
+			},
+		},
+	}
+
+	for _, test := range table {
+		called = false
+		actual := mcpserver.HandleRequest(types.NewJSONRPCRequest(types.NewStringId("id"), CallTool, types.NewCallToolParams("ls", test.arguments)))
+
+		if test.expectedViolations == nil {
+			if actual.Error != nil || !called {
+				t.Errorf("Expected the tool to be called but got %#v", actual.Error)
+			}
+			continue
+		}
+
+		expected := types.NewJSONRPCErrorObj(ErrInvalidParams, "Invalid parameters", test.expectedViolations)
+		if !reflect.DeepEqual(actual.Error, expected) {
+			t.Errorf("Expected %#v but got %#v", expected, actual.Error)
+		}
+
+		if called {
+			t.Errorf("Expected the tool not to be called")
+		}
+	}
+}
+
 func TestHandleRequestWithReadResource(t *testing.T) {
 	mcpserver, teardown := setupTest(t)
 	defer teardown(t)
+	initializeTest(t, mcpserver)
 
 	content := []types.OperationContent{*types.NewOperationContent("text", "content", "", nil)}
 
@@ -171,20 +473,20 @@ func TestHandleRequestWithReadResource(t *testing.T) {
 		expected *types.JSONRPCResponse
 	}{
 		{
-			types.NewJSONRPCRequest("id", ReadResource, types.NewReadResourceParams("file://resource.tst")),
-			types.NewJSONRPCResponse("id", types.NewReadResourceResult(content), nil),
+			types.NewJSONRPCRequest(types.NewStringId("id"), ReadResource, types.NewReadResourceParams("file://resource.tst")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewReadResourceResult(content), nil),
 		},
 		{
-			types.NewJSONRPCRequest("id", ReadResource, types.NewReadResourceParams("file://unknown.txt")),
-			types.NewJSONRPCResponse("id", nil, types.NewJSONRPCErrorObj(ErrMethodNotFound, "Unknown Resource", ReadResource)),
+			types.NewJSONRPCRequest(types.NewStringId("id"), ReadResource, types.NewReadResourceParams("file://unknown.txt")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrMethodNotFound, "Unknown Resource", ReadResource)),
 		},
 		{
-			types.NewJSONRPCRequest("id", ReadResource, types.NewShutdownParams()),
-			types.NewJSONRPCResponse("id", nil, types.NewJSONRPCErrorObj(ErrInvalidParams, "Invalid parameters", ReadResource)),
+			types.NewJSONRPCRequest(types.NewStringId("id"), ReadResource, types.NewShutdownParams()),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrInvalidParams, "Invalid parameters", ReadResource)),
 		},
 		{
-			types.NewJSONRPCRequest("id", ReadResource, types.NewReadResourceParams("file://error_resource")),
-			types.NewJSONRPCResponse("id", nil, types.NewJSONRPCErrorObj(ErrServerGeneric, "Error reading resource error_resource", err.Error())),
+			types.NewJSONRPCRequest(types.NewStringId("id"), ReadResource, types.NewReadResourceParams("file://error_resource")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrServerGeneric, "Error reading resource error_resource", err.Error())),
 		},
 	}
 
@@ -258,6 +560,14 @@ func TestAddToolFuncWithInvalidSignature(t *testing.T) {
 			func(_ struct{}) (any, error) { return nil, nil },
 			ErrHandlerWrongReturns,
 		},
+		{
+			func(_ string, _ struct{}) (*types.ToolResult, error) { return nil, nil },
+			ErrHandlerWrongArgs,
+		},
+		{
+			func(_ context.Context, _ string) (*types.ToolResult, error) { return nil, nil },
+			ErrHandlerArgNotStruct,
+		},
 	}
 
 	for _, test := range table {
@@ -278,16 +588,17 @@ func TestAddTooFunc(t *testing.T) {
 	defer teardown(t)
 
 	expectedInputSpec := map[string]any{
-		"type":       "object",
-		"properties": map[string]any{"test": map[string]any{"type": "string"}},
-		"required":   []string{"test"},
+		"type":                 "object",
+		"properties":           map[string]any{"test": map[string]any{"type": "string"}},
+		"required":             []string{"test"},
+		"additionalProperties": false,
 	}
 
 	expectedName := "tool"
 	expectedDescription := "tool"
 
 	type ExpectedhandlerArgs struct {
-		test string
+		Test string `json:"test"`
 	}
 
 	expectedResult := types.NewToolResult([]types.OperationContent{*types.NewOperationContent("text", "content", "", nil)})
@@ -329,6 +640,277 @@ func TestAddTooFunc(t *testing.T) {
 	}
 }
 
+func TestAddToolFuncWithContext(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	type Args struct {
+		Test string `json:"test"`
+	}
+
+	var requestCtx context.Context
+	err := mcpserver.AddToolFunc("tool", "tool", func(ctx context.Context, args Args) (*types.ToolResult, error) {
+		requestCtx = ctx
+
+		id, _ := RequestIdFromContext(ctx)
+		clientInfo, _ := ClientInfoFromContext(ctx)
+		text := fmt.Sprintf("%s %s %s %s", args.Test, id, clientInfo.Name, LoggerFromContext(ctx).Prefix())
+		return types.NewToolResult([]types.OperationContent{*types.NewOperationContent("text", text, "", nil)}), nil
+	})
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+
+	actual := mcpserver.HandleRequest(types.NewJSONRPCRequest(types.NewNumberId(7), CallTool, types.NewCallToolParams("tool", map[string]any{"test": "value"})))
+	expected := types.NewJSONRPCResponse(types.NewNumberId(7), types.NewToolResult([]types.OperationContent{*types.NewOperationContent("text", "value 7 test [tools/call 7] ", "", nil)}), nil)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %#v but got %#v", expected, actual)
+	}
+
+	if SessionFromContext(requestCtx) != mcpserver.Session() {
+		t.Errorf("Expected the session of the server in the request context")
+	}
+
+	if !errors.Is(requestCtx.Err(), context.Canceled) {
+		t.Errorf("Expected the request context cancelled after the response but got %v", requestCtx.Err())
+	}
+
+	// outside of a request, the helpers return the zero values
+	if _, ok := RequestIdFromContext(context.Background()); ok {
+		t.Errorf("Expected no request id in a background context")
+	}
+
+	if SessionFromContext(context.Background()) != nil {
+		t.Errorf("Expected no session in a background context")
+	}
+
+	if LoggerFromContext(context.Background()) != log.Default() {
+		t.Errorf("Expected the standard logger in a background context")
+	}
+}
+
+func TestRequestContextCancelledOnExit(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	started := make(chan struct{})
+	mcpserver.AddTool(types.NewContextTool("wait", "wait", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+
+	responses := make(chan string)
+	go func() {
+		response, _ := mcpserver.Handle(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"wait"}}`)
+		responses <- response
+	}()
+
+	<-started
+	mcpserver.Handle(`{"jsonrpc":"2.0","method":"exit"}`)
+
+	expected := `{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"context canceled"}],"isError":true}}`
+	if actual := <-responses; actual != expected {
+		t.Errorf("Expected %s but got %s", expected, actual)
+	}
+}
+
+func TestHandleWithCancelledRequest(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	started := make(chan struct{})
+	mcpserver.AddTool(types.NewContextTool("wait", "wait", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		close(started)
+		<-ctx.Done()
+
+		reason, _ := CancellationReasonFromContext(ctx)
+		return types.NewToolResult([]types.OperationContent{*types.NewOperationContent("text", reason, "", nil)}), nil
+	}))
+
+	responses := make(chan string)
+	go func() {
+		response, _ := mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"wait"}}`)
+		responses <- response
+	}()
+
+	<-started
+	response, _ := mcpserver.Handle(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"call","reason":"timeout"}}`)
+	if response != "" {
+		t.Errorf("Expected no response to the notification but got %s", response)
+	}
+
+	if actual := <-responses; actual != "" {
+		t.Errorf("Expected no response to the cancelled request but got %s", actual)
+	}
+
+	// cancelling a completed request is ignored
+	if mcpserver.Session().cancelRequest(`"call"`, &CancelledError{}) {
+		t.Errorf("Expected the completed request not to be in progress")
+	}
+
+	if _, ok := CancellationReasonFromContext(context.Background()); ok {
+		t.Errorf("Expected no cancellation reason in a background context")
+	}
+}
+
+func TestReadContextResource(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	reader := func(ctx context.Context, uri string) ([]types.OperationContent, error) {
+		id, _ := RequestIdFromContext(ctx)
+		return []types.OperationContent{*types.NewOperationContent("text", id.String(), uri, nil)}, nil
+	}
+	templateReader := func(ctx context.Context, uri string, vars map[string]string) ([]types.OperationContent, error) {
+		id, _ := RequestIdFromContext(ctx)
+		return []types.OperationContent{*types.NewOperationContent("text", id.String()+" "+vars["name"], uri, nil)}, nil
+	}
+
+	mcpserver.AddResource(types.NewContextResource("resource", "resource", "file:///resource", reader))
+	if err := mcpserver.AddResourceTemplate(types.NewContextResourceTemplate("template", "template", "file:///{name}.txt", templateReader)); err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+
+	table := []struct {
+		req      *types.JSONRPCRequest
+		expected *types.JSONRPCResponse
+	}{
+		{
+			types.NewJSONRPCRequest(types.NewStringId("r1"), ReadResource, types.NewReadResourceParams("file:///resource")),
+			types.NewJSONRPCResponse(types.NewStringId("r1"), types.NewReadResourceResult([]types.OperationContent{*types.NewOperationContent("text", `"r1"`, "file:///resource", nil)}), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("r2"), ReadResource, types.NewReadResourceParams("file:///notes.txt")),
+			types.NewJSONRPCResponse(types.NewStringId("r2"), types.NewReadResourceResult([]types.OperationContent{*types.NewOperationContent("text", `"r2" notes`, "file:///notes.txt", nil)}), nil),
+		},
+	}
+
+	for _, test := range table {
+		actual := mcpserver.HandleRequest(test.req)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %#v but got %#v", test.expected, actual)
+		}
+	}
+}
+
+func TestAddStructuredToolFunc(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	type Args struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+
+	type Output struct {
+		Sum  int    `json:"sum"`
+		Note string `json:"note,omitempty" jsonschema:"maxLength=3"`
+	}
+
+	err := mcpserver.AddStructuredToolFunc("sum", "sum two numbers", func(args Args) (*Output, error) {
+		if args.A == 0 && args.B == 0 {
+			return nil, nil
+		}
+		if args.A < 0 {
+			return &Output{Sum: args.A + args.B, Note: "negative"}, nil
+		}
+		return &Output{Sum: args.A + args.B}, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+
+	expectedOutputSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"sum":  map[string]any{"type": "integer"},
+			"note": map[string]any{"type": "string", "maxLength": 3},
+		},
+		"required":             []string{"sum"},
+		"additionalProperties": false,
+	}
+	if !reflect.DeepEqual(mcpserver.tools["sum"].OutputSchema, expectedOutputSchema) {
+		t.Errorf("Expected %v but got %v", expectedOutputSchema, mcpserver.tools["sum"].OutputSchema)
+	}
+
+	table := []struct {
+		request          string
+		expectedResponse string
+	}{
+		{
+			`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"sum","arguments":{"a":1,"b":2}}}`,
+			`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"{\"sum\":3}"}],"structuredContent":{"sum":3}}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"sum","arguments":{"a":-1,"b":2}}}`,
+			`{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"{\"sum\":1,\"note\":\"negative\"}"}],"structuredContent":{"sum":1,"note":"negative"}}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"sum","arguments":{"a":0,"b":0}}}`,
+			`{"jsonrpc":"2.0","id":3,"error":{"code":-32603,"message":"Invalid structured content of tool sum","data":"structured handler returned a nil output"}}`,
+		},
+	}
+
+	for _, test := range table {
+		actualResponse, _ := mcpserver.Handle(test.request)
+		if actualResponse != test.expectedResponse {
+			t.Errorf("Expected %s but got %s", test.expectedResponse, actualResponse)
+		}
+	}
+
+	mcpserver.WithDebug(true)
+
+	actualResponse, _ := mcpserver.Handle(table[1].request)
+	expectedResponse := `{"jsonrpc":"2.0","id":2,"error":{"code":-32603,"message":"Invalid structured content of tool sum","data":[{"path":"/note","keyword":"maxLength","message":"must be at most 3 characters long"}]}}`
+	if actualResponse != expectedResponse {
+		t.Errorf("Expected %s but got %s", expectedResponse, actualResponse)
+	}
+}
+
+func TestAddStructuredToolFuncWithInvalidSignature(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	table := []struct {
+		handler  any
+		expected error
+	}{
+		{
+			nil,
+			ErrHandlerNotFunction,
+		},
+		{
+			func(_ struct{}) struct{} { return struct{}{} },
+			ErrStructuredHandlerWrongReturns,
+		},
+		{
+			func(_ string) (struct{}, error) { return struct{}{}, nil },
+			ErrHandlerArgNotStruct,
+		},
+		{
+			func(_ struct{}) (string, error) { return "", nil },
+			ErrHandlerOutputNotStruct,
+		},
+		{
+			func(_ struct{}) (struct{}, string) { return struct{}{}, "" },
+			ErrStructuredHandlerWrongReturns,
+		},
+	}
+
+	for _, test := range table {
+		err := mcpserver.AddStructuredToolFunc("", "", test.handler)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("Expected %#v but got %#v", test.expected, err)
+		}
+	}
+}
+
 func TestAddResource(t *testing.T) {
 	mcpserver, teardown := setupTest(t)
 	defer teardown(t)
@@ -352,6 +934,65 @@ func TestAddResource(t *testing.T) {
 	}
 }
 
+func TestHandleRequestWithResourceTemplate(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	exact := []types.OperationContent{*types.NewOperationContent("text", "exact", "", nil)}
+	mcpserver.AddResource(types.NewResource("readme", "readme", "file:///README.md", func(uri string) ([]types.OperationContent, error) {
+		return exact, nil
+	}))
+
+	err := mcpserver.AddResourceTemplate(types.NewResourceTemplate("file", "any file", "file:///{+path}", func(uri string, vars map[string]string) ([]types.OperationContent, error) {
+		if vars["path"] == "missing.txt" {
+			return nil, errors.New("file not found")
+		}
+		return []types.OperationContent{*types.NewOperationContent("text", vars["path"], uri, nil)}, nil
+	}))
+	if err != nil {
+		t.Fatalf("expected nil but got %v", err)
+	}
+
+	if err := mcpserver.AddResourceTemplate(types.NewResourceTemplate("bad", "bad", "file:///{path", nil)); err == nil {
+		t.Errorf("expected error for malformed template but got nil")
+	}
+
+	templates := mcpserver.ResourceTemplates()
+	if len(templates) != 1 || templates[0].URITemplate != "file:///{+path}" {
+		t.Errorf("expected 1 template but got %v", templates)
+	}
+
+	table := []struct {
+		req      *types.JSONRPCRequest
+		expected *types.JSONRPCResponse
+	}{
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), ReadResource, types.NewReadResourceParams("file:///README.md")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewReadResourceResult(exact), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), ReadResource, types.NewReadResourceParams("file:///docs/guide.md")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewReadResourceResult([]types.OperationContent{*types.NewOperationContent("text", "docs/guide.md", "file:///docs/guide.md", nil)}), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), ReadResource, types.NewReadResourceParams("file:///missing.txt")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrServerGeneric, "Error reading resource file", "file not found")),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), ReadResource, types.NewReadResourceParams("http://example.com")),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrMethodNotFound, "Unknown Resource", ReadResource)),
+		},
+	}
+
+	for _, test := range table {
+		actual := mcpserver.HandleRequest(test.req)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %#v but got %#v", test.expected, actual)
+		}
+	}
+}
+
 func TestHandle(t *testing.T) {
 	mcpserver, teardown := setupTest(t)
 	defer teardown(t)
@@ -361,13 +1002,13 @@ func TestHandle(t *testing.T) {
 		expectedResponse string
 	}{
 		{
-			`{"jsonrpc":"2.0","id":"id1","method":"initialize","params":{"clientName":"testClient","clientVersion":"1.0"}}`,
-			fmt.Sprintf(`{"jsonrpc":"2.0","id":"id1","result":{"serverInfo":{"name":"%v","version":"%v"},"capabilities":{"tools":%v,"resources":%v}}}`,
-				mcpserver.name, mcpserver.version, len(mcpserver.tools) > 0, len(mcpserver.resources) > 0),
+			`{"jsonrpc":"2.0","id":"id1","method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"testClient","version":"1.0"}}}`,
+			fmt.Sprintf(`{"jsonrpc":"2.0","id":"id1","result":{"protocolVersion":"2025-03-26","serverInfo":{"name":"%v","version":"%v"},"capabilities":{}}}`,
+				mcpserver.name, mcpserver.version),
 		},
 		{
-			`{"jsonrpc":"2.0","id":"id2","method":"shutdown","params":{}}`,
-			`{"jsonrpc":"2.0","id":"id2","result":{"message":"MCP Session terminated"}}`,
+			`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			``,
 		},
 		{
 			`{"jsonrpc":"2.0","id":"id3","method":"unknown_method","params":{}}`,
@@ -391,7 +1032,15 @@ func TestHandle(t *testing.T) {
 		},
 		{
 			`invalid_json`,
-			`{"jsonrpc":"2.0","id":"","error":{"code":-32700,"message":"Parse error","data":"invalid character 'i' looking for beginning of value"}}`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error","data":"invalid character 'i' looking for beginning of value"}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":"id2","method":"shutdown","params":{}}`,
+			`{"jsonrpc":"2.0","id":"id2","result":{"message":"MCP Session terminated"}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":"id8","method":"tools/list","params":{}}`,
+			`{"jsonrpc":"2.0","id":"id8","error":{"code":-32600,"message":"Invalid Request","data":"session is shutting down"}}`,
 		},
 	}
 
@@ -403,14 +1052,164 @@ func TestHandle(t *testing.T) {
 	}
 }
 
+func TestHandleWithIds(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	table := []struct {
+		request          string
+		expectedResponse string
+	}{
+		{
+			`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":1,"result":{"tools":[]}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":12345678901234567890,"method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":12345678901234567890,"result":{"tools":[]}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":"abc","method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":"abc","result":{"tools":[]}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":null,"method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":null,"result":{"tools":[]}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":{"a":1},"method":"tools/list"}`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"id must be a string, a number or null"}}`,
+		},
+	}
+
+	for _, test := range table {
+		actualResponse, _ := mcpserver.Handle(test.request)
+		if actualResponse != test.expectedResponse {
+			t.Errorf("Expected %s but got %s", test.expectedResponse, actualResponse)
+		}
+	}
+}
+
+func TestHandleWithNotifications(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	var received []any
+	mcpserver.OnNotification(Initialized, func(params any) {
+		received = append(received, params)
+	})
+
+	table := []struct {
+		request          string
+		expectedResponse string
+	}{
+		{
+			`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			``,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`,
+			``,
+		},
+		{
+			`{"jsonrpc":"2.0","method":"unknown_notification"}`,
+			``,
+		},
+		{
+			`{"jsonrpc":"2.0","id":null,"method":"unknown_method"}`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32601,"message":"Method Not Found","data":"unknown_method"}}`,
+		},
+	}
+
+	for _, test := range table {
+		actualResponse, _ := mcpserver.Handle(test.request)
+		if actualResponse != test.expectedResponse {
+			t.Errorf("Expected %s but got %s", test.expectedResponse, actualResponse)
+		}
+	}
+
+	if len(received) != 1 {
+		t.Errorf("expected 1 notification but got %v", len(received))
+	}
+
+	if response := mcpserver.HandleRequest(&types.JSONRPCRequest{JSONRPC: "2.0", Method: Initialized}); response != nil {
+		t.Errorf("expected nil but got %v", response)
+	}
+}
+
+func TestHandleWithBatch(t *testing.T) {
+	table := []struct {
+		request          string
+		expectedResponse string
+	}{
+		{
+			`[{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","method":"notifications/initialized"},{"jsonrpc":"2.0","id":"2","method":"unknown_method"}]`,
+			`[{"jsonrpc":"2.0","id":1,"result":{"tools":[]}},{"jsonrpc":"2.0","id":"2","error":{"code":-32601,"message":"Method Not Found","data":"unknown_method"}}]`,
+		},
+		{
+			`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`,
+			``,
+		},
+		{
+			`[]`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"empty batch"}}`,
+		},
+		{
+			`[1]`,
+			`[{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"json: cannot unmarshal number into Go value of type types.JSONRPCRequest"}}]`,
+		},
+		{
+			`[{"jsonrpc":"2.0","id":1,"method":"tools/list"},`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error","data":"unexpected end of JSON input"}}`,
+		},
+	}
+
+	for _, concurrency := range []int{0, 4} {
+		mcpserver, teardown := setupTest(t)
+		mcpserver.WithBatchConcurrency(concurrency)
+		initializeTest(t, mcpserver)
+
+		for _, test := range table {
+			actualResponse, _ := mcpserver.Handle(test.request)
+			if actualResponse != test.expectedResponse {
+				t.Errorf("Expected %s but got %s", test.expectedResponse, actualResponse)
+			}
+		}
+
+		teardown(t)
+	}
+}
+
+func TestRequestIdMarshalJSON(t *testing.T) {
+	table := []struct {
+		id       types.RequestId
+		expected string
+	}{
+		{types.NewStringId("id"), `"id"`},
+		{types.NewNumberId(42), `42`},
+		{types.NullId(), `null`},
+		{types.RequestId{}, `null`},
+	}
+
+	for _, test := range table {
+		actual, err := json.Marshal(test.id)
+		if err != nil || string(actual) != test.expected {
+			t.Errorf("Expected %s but got %s (%v)", test.expected, actual, err)
+		}
+	}
+}
+
 func TestHandleWithTools(t *testing.T) {
 	mcpserver, teardown := setupTest(t)
 	defer teardown(t)
 
 	expectedInputSpec := map[string]any{
-		"type":       "object",
-		"properties": map[string]any{"test": map[string]any{"type": "string"}},
-		"required":   []string{"test"},
+		"type":                 "object",
+		"properties":           map[string]any{"test": map[string]any{"type": "string"}},
+		"required":             []string{"test"},
+		"additionalProperties": false,
 	}
 
 	expectedInputSchema, _ := json.Marshal(expectedInputSpec)
@@ -418,7 +1217,7 @@ func TestHandleWithTools(t *testing.T) {
 	expectedDescription := "tool"
 
 	type ExpectedhandlerArgs struct {
-		test string
+		Test string `json:"test"`
 	}
 
 	expectedResult := types.NewToolResult([]types.OperationContent{*types.NewOperationContent("text", "content", "", nil)})
@@ -433,9 +1232,13 @@ func TestHandleWithTools(t *testing.T) {
 		expectedResponse string
 	}{
 		{
-			`{"jsonrpc":"2.0","id":"id1","method":"initialize","params":{"clientName":"testClient","clientVersion":"1.0"}}`,
-			fmt.Sprintf(`{"jsonrpc":"2.0","id":"id1","result":{"serverInfo":{"name":"%v","version":"%v"},"capabilities":{"tools":%v,"resources":%v}}}`,
-				mcpserver.name, mcpserver.version, true, false),
+			`{"jsonrpc":"2.0","id":"id1","method":"initialize","params":{"protocolVersion":"2025-06-18","clientInfo":{"name":"testClient","version":"1.0"}}}`,
+			fmt.Sprintf(`{"jsonrpc":"2.0","id":"id1","result":{"protocolVersion":"2025-06-18","serverInfo":{"name":"%v","version":"%v"},"capabilities":{"tools":{}}}}`,
+				mcpserver.name, mcpserver.version),
+		},
+		{
+			`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+			``,
 		},
 		{
 			`{"jsonrpc":"2.0","id":"id4","method":"tools/list","params":{}}`,
@@ -459,3 +1262,112 @@ func TestHandleWithTools(t *testing.T) {
 		}
 	}
 }
+
+func TestAddPromptFunc(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	type ReviewArgs struct {
+		Code     string `json:"code" jsonschema:"description=The code to review"`
+		Language string `json:"language,omitempty"`
+		ignored  string
+	}
+
+	handler := func(args ReviewArgs) ([]types.PromptMessage, error) {
+		text := fmt.Sprintf("Review this %s code: %s", args.Language, args.Code)
+		return []types.PromptMessage{*types.NewPromptMessage(types.RoleUser, *types.NewOperationContent("text", text, "", nil))}, nil
+	}
+
+	if err := mcpserver.AddPromptFunc("review", "review code", handler); err != nil {
+		t.Fatalf("expected nil but got %v", err)
+	}
+
+	prompts := mcpserver.Prompts()
+	if len(prompts) != 1 {
+		t.Fatalf("expected 1 but got %v", len(prompts))
+	}
+
+	expectedArguments := []types.PromptArgument{
+		*types.NewPromptArgument("code", "The code to review", true),
+		*types.NewPromptArgument("language", "", false),
+	}
+	if !reflect.DeepEqual(prompts[0].Arguments, expectedArguments) {
+		t.Errorf("expected %v but got %v", expectedArguments, prompts[0].Arguments)
+	}
+
+	expectedMessages := []types.PromptMessage{
+		*types.NewPromptMessage(types.RoleUser, *types.NewOperationContent("text", "Review this go code: x := 1", "", nil)),
+	}
+
+	table := []struct {
+		req      *types.JSONRPCRequest
+		expected *types.JSONRPCResponse
+	}{
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), GetPrompt, types.NewGetPromptParams("review", map[string]string{"code": "x := 1", "language": "go"})),
+			types.NewJSONRPCResponse(types.NewStringId("id"), types.NewGetPromptResult("review code", expectedMessages), nil),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), GetPrompt, types.NewGetPromptParams("review", map[string]string{"language": "go"})),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrInvalidParams, "Missing required argument", "code")),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), GetPrompt, types.NewGetPromptParams("unknown", nil)),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrMethodNotFound, "Unknown Prompt", GetPrompt)),
+		},
+		{
+			types.NewJSONRPCRequest(types.NewStringId("id"), GetPrompt, types.NewShutdownParams()),
+			types.NewJSONRPCResponse(types.NewStringId("id"), nil, types.NewJSONRPCErrorObj(ErrInvalidParams, "Invalid parameters", GetPrompt)),
+		},
+	}
+
+	for _, test := range table {
+		actual := mcpserver.HandleRequest(test.req)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("Expected %#v but got %#v", test.expected, actual)
+		}
+	}
+}
+
+func TestAddPromptFuncWithInvalidSignature(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+
+	table := []struct {
+		handler  any
+		expected error
+	}{
+		{
+			"not a function",
+			ErrHandlerNotFunction,
+		},
+		{
+			nil,
+			ErrHandlerNotFunction,
+		},
+		{
+			func() ([]types.PromptMessage, error) { return nil, nil },
+			ErrHandlerWrongArgs,
+		},
+		{
+			func(_ string) ([]types.PromptMessage, error) { return nil, nil },
+			ErrHandlerArgNotStruct,
+		},
+		{
+			func(_ struct{}) (*types.ToolResult, error) { return nil, nil },
+			ErrPromptHandlerWrongReturns,
+		},
+		{
+			func(_ struct{ Count int }) ([]types.PromptMessage, error) { return nil, nil },
+			ErrPromptArgNotString,
+		},
+	}
+
+	for _, test := range table {
+		err := mcpserver.AddPromptFunc("", "", test.handler)
+		if !errors.Is(err, test.expected) {
+			t.Errorf("Expected %#v but got %#v", test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package gomcp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// DefaultProgressInterval is the minimum interval between two progress notifications of a request.
+const DefaultProgressInterval = 100 * time.Millisecond
+
+var ErrProgressNotIncreasing = errors.New("progress must increase with each notification")
+
+// ProgressReporter sends notifications/progress for a request whose client asked for them
+// with a progress token. Notifications closer than the progress interval of the server are
+// dropped, except the final one, so that a handler can report as often as it likes.
+type ProgressReporter struct {
+	session  *Session
+	sender   Sender
+	token    any
+	interval time.Duration
+
+	mu       sync.Mutex
+	sent     bool
+	last     time.Time
+	progress float64
+}
+
+// newProgressReporter returns the ProgressReporter for req, sending the notifications through sender
+// or the sender of the session when nil, or nil when the client didn't send a progress token in the
+// _meta of the params.
+func newProgressReporter(session *Session, sender Sender, req *types.JSONRPCRequest, interval time.Duration) *ProgressReporter {
+	params, _ := req.Params.(map[string]any)
+	meta, _ := params["_meta"].(map[string]any)
+	token, ok := meta["progressToken"]
+	if !ok || token == nil {
+		return nil
+	}
+
+	return &ProgressReporter{session: session, sender: sender, token: token, interval: interval}
+}
+
+// Report sends the progress of the request to the client. total is 0 when unknown and message is optional.
+// It does nothing when the client didn't ask for progress, and it drops the notifications sent too often,
+// except the one where progress reaches total.
+func (p *ProgressReporter) Report(progress, total float64, message string) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sent && progress <= p.progress {
+		return ErrProgressNotIncreasing
+	}
+
+	final := total > 0 && progress >= total
+	if p.sent && !final && time.Since(p.last) < p.interval {
+		return nil
+	}
+
+	p.sent = true
+	p.last = time.Now()
+	p.progress = progress
+
+	return p.session.notify(p.sender, Progress, types.NewProgressParams(p.token, progress, total, message))
+}
+
+// ProgressFromContext returns the ProgressReporter of the request being handled. The returned reporter
+// is nil, and reporting does nothing, when the client didn't ask for progress notifications.
+func ProgressFromContext(ctx context.Context) *ProgressReporter {
+	reporter, _ := ctx.Value(progressKey).(*ProgressReporter)
+	return reporter
+}
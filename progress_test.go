@@ -0,0 +1,99 @@
+package gomcp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+func TestProgressReporter(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+	mcpserver.WithProgressInterval(time.Hour)
+
+	messages := []string{}
+	mcpserver.Session().SetSender(func(message string) error {
+		messages = append(messages, message)
+		return nil
+	})
+
+	var errs []error
+	mcpserver.AddTool(types.NewContextTool("index", "index", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		progress := ProgressFromContext(ctx)
+		errs = append(errs,
+			progress.Report(1, 3, "started"),
+			progress.Report(2, 3, "dropped"),
+			progress.Report(1, 3, "not increasing"),
+			progress.Report(3, 3, "done"),
+		)
+		return types.NewToolResult(nil), nil
+	}))
+
+	table := []struct {
+		request          string
+		expectedMessages []string
+		expectedErrs     []error
+	}{
+		{
+			`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"index","_meta":{"progressToken":"token"}}}`,
+			[]string{
+				`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"token","progress":1,"total":3,"message":"started"}}`,
+				`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"token","progress":3,"total":3,"message":"done"}}`,
+			},
+			[]error{nil, nil, ErrProgressNotIncreasing, nil},
+		},
+		{
+			`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"index","_meta":{"progressToken":42}}}`,
+			[]string{
+				`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":42,"progress":1,"total":3,"message":"started"}}`,
+				`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":42,"progress":3,"total":3,"message":"done"}}`,
+			},
+			[]error{nil, nil, ErrProgressNotIncreasing, nil},
+		},
+		{
+			`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"index"}}`,
+			[]string{},
+			[]error{nil, nil, nil, nil},
+		},
+	}
+
+	for _, test := range table {
+		messages = []string{}
+		errs = nil
+
+		mcpserver.Handle(test.request)
+
+		if !reflect.DeepEqual(messages, test.expectedMessages) {
+			t.Errorf("Expected %#v but got %#v", test.expectedMessages, messages)
+		}
+
+		for i, err := range errs {
+			if !errors.Is(err, test.expectedErrs[i]) {
+				t.Errorf("Expected %#v but got %#v", test.expectedErrs[i], err)
+			}
+		}
+	}
+}
+
+func TestProgressReporterWithoutSender(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeTest(t, mcpserver)
+
+	var err error
+	mcpserver.AddTool(types.NewContextTool("index", "index", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		err = ProgressFromContext(ctx).Report(1, 0, "")
+		return types.NewToolResult(nil), nil
+	}))
+
+	mcpserver.Handle(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"index","_meta":{"progressToken":"token"}}}`)
+
+	if !errors.Is(err, ErrSessionNoSender) {
+		t.Errorf("Expected %#v but got %#v", ErrSessionNoSender, err)
+	}
+}
@@ -0,0 +1,89 @@
+package gomcp
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+const (
+	ListRoots        = "roots/list"
+	RootsListChanged = "notifications/roots/list_changed"
+)
+
+var ErrRootsNotSupported = errors.New("client does not support roots")
+
+// RootsFromContext returns the roots exposed by the client of the request being handled, so that tools
+// can restrict themselves to them. The roots are requested with roots/list once the session is initialized,
+// and again when the client notifies that they changed; when they are not available yet, they are
+// requested and awaited. ctx must be the context of the request, and the client must have advertised
+// the roots capability.
+func RootsFromContext(ctx context.Context) ([]types.Root, error) {
+	session := SessionFromContext(ctx)
+	if session == nil {
+		return nil, ErrNoSession
+	}
+
+	if session.ClientCapabilities().Roots == nil {
+		return nil, ErrRootsNotSupported
+	}
+
+	if roots, ok := session.cachedRoots(); ok {
+		return roots, nil
+	}
+
+	return session.refreshRoots(ctx)
+}
+
+// refreshRoots requests the roots to the client and caches them. The roots are not cached
+// when they were invalidated in the meantime, as the result may be outdated.
+func (s *Session) refreshRoots(ctx context.Context) ([]types.Root, error) {
+	s.mu.RLock()
+	version := s.rootsVersion
+	s.mu.RUnlock()
+
+	var result types.ListRootsResult
+	if err := s.request(ctx, ListRoots, nil, &result); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version == s.rootsVersion {
+		s.roots = result.Roots
+		s.rootsCached = true
+	}
+
+	return result.Roots, nil
+}
+
+// cachedRoots returns the roots of the client, and false when they are not cached.
+func (s *Session) cachedRoots() ([]types.Root, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.roots, s.rootsCached
+}
+
+// invalidateRoots discards the cached roots, after the client notified that they changed.
+func (s *Session) invalidateRoots() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.roots = nil
+	s.rootsCached = false
+	s.rootsVersion++
+}
+
+// prefetchRoots requests the roots to the client in the background, when the client supports them.
+func (s *Session) prefetchRoots() {
+	if s.ClientCapabilities().Roots == nil {
+		return
+	}
+
+	go func() {
+		if _, err := s.refreshRoots(s.ctx); err != nil {
+			log.Printf("Error requesting the roots: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,113 @@
+package gomcp
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// waitRootsTest waits until the roots of session are cached.
+func waitRootsTest(tb testing.TB, session *Session) {
+	for range 1000 {
+		if _, ok := session.cachedRoots(); ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	tb.Fatalf("Expected the roots to be cached")
+}
+
+func TestRootsFromContext(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	messages := senderTest(mcpserver)
+	initializeWithCapabilitiesTest(t, mcpserver, `{"roots":{"listChanged":true}}`)
+
+	var roots []types.Root
+	var err error
+	mcpserver.AddTool(types.NewContextTool("roots", "roots", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		roots, err = RootsFromContext(ctx)
+		return types.NewToolResult(nil), nil
+	}))
+
+	table := []struct {
+		notification   string
+		expectedId     string
+		clientResponse string
+		expectedRoots  []types.Root
+	}{
+		{
+			// the roots are requested once the session is initialized
+			"",
+			"1",
+			`{"jsonrpc":"2.0","id":1,"result":{"roots":[{"uri":"file:///home/user/project","name":"project"}]}}`,
+			[]types.Root{*types.NewRoot("file:///home/user/project", "project")},
+		},
+		{
+			`{"jsonrpc":"2.0","method":"notifications/roots/list_changed"}`,
+			"2",
+			`{"jsonrpc":"2.0","id":2,"result":{"roots":[{"uri":"file:///home/user/project"},{"uri":"file:///tmp"}]}}`,
+			[]types.Root{*types.NewRoot("file:///home/user/project", ""), *types.NewRoot("file:///tmp", "")},
+		},
+	}
+
+	for _, test := range table {
+		if test.notification != "" {
+			mcpserver.Handle(test.notification)
+		}
+
+		expected := `{"jsonrpc":"2.0","id":` + test.expectedId + `,"method":"roots/list"}`
+		if actual := <-messages; actual != expected {
+			t.Errorf("Expected %s but got %s", expected, actual)
+		}
+
+		mcpserver.Handle(test.clientResponse)
+		waitRootsTest(t, mcpserver.Session())
+
+		// the cached roots are returned without requesting them again
+		mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"roots"}}`)
+
+		if err != nil {
+			t.Errorf("Expected nil but got %v", err)
+		}
+
+		if !reflect.DeepEqual(roots, test.expectedRoots) {
+			t.Errorf("Expected %#v but got %#v", test.expectedRoots, roots)
+		}
+
+		if len(messages) > 0 {
+			t.Errorf("Expected no more messages but got %s", <-messages)
+		}
+	}
+}
+
+func TestRootsFromContextNotSupported(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	messages := senderTest(mcpserver)
+	initializeWithCapabilitiesTest(t, mcpserver, `{}`)
+
+	var err error
+	mcpserver.AddTool(types.NewContextTool("roots", "roots", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		_, err = RootsFromContext(ctx)
+		return types.NewToolResult(nil), nil
+	}))
+
+	mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"roots"}}`)
+
+	if !errors.Is(err, ErrRootsNotSupported) {
+		t.Errorf("Expected %#v but got %#v", ErrRootsNotSupported, err)
+	}
+
+	if len(messages) > 0 {
+		t.Errorf("Expected no roots/list request but got %s", <-messages)
+	}
+
+	if _, err := RootsFromContext(context.Background()); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Expected %#v but got %#v", ErrNoSession, err)
+	}
+}
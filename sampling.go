@@ -0,0 +1,37 @@
+package gomcp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+const SamplingCreateMessage = "sampling/createMessage"
+
+var (
+	ErrNoSession            = errors.New("no session in context")
+	ErrSamplingNotSupported = errors.New("client does not support sampling")
+)
+
+// CreateMessage asks the client of the request being handled to generate a message with its LLM,
+// e.g. to summarize or classify a text, and waits for the result. ctx must be the context of the
+// request, and the client must have advertised the sampling capability. The request is cancelled
+// when ctx is done, or after DefaultRequestTimeout when ctx has no deadline.
+func CreateMessage(ctx context.Context, params *types.CreateMessageParams) (*types.CreateMessageResult, error) {
+	session := SessionFromContext(ctx)
+	if session == nil {
+		return nil, ErrNoSession
+	}
+
+	if session.ClientCapabilities().Sampling == nil {
+		return nil, ErrSamplingNotSupported
+	}
+
+	var result types.CreateMessageResult
+	if err := session.request(ctx, SamplingCreateMessage, params, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
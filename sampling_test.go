@@ -0,0 +1,150 @@
+package gomcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// initializeWithCapabilitiesTest initializes the session of mcpserver for a client with the given capabilities.
+func initializeWithCapabilitiesTest(tb testing.TB, mcpserver *MCPServer, capabilities string) {
+	mcpserver.Handle(`{"jsonrpc":"2.0","id":"init","method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":` + capabilities + `,"clientInfo":{"name":"test","version":"1.0"}}}`)
+	mcpserver.Handle(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	if mcpserver.Session().State() != SessionReady {
+		tb.Fatalf("Expected %v but got %v", SessionReady, mcpserver.Session().State())
+	}
+}
+
+// senderTest sets a sender on the session of mcpserver, returning the channel of the messages sent to the client.
+func senderTest(mcpserver *MCPServer) chan string {
+	messages := make(chan string, 10)
+	mcpserver.Session().SetSender(func(message string) error {
+		messages <- message
+		return nil
+	})
+	return messages
+}
+
+func TestCreateMessage(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeWithCapabilitiesTest(t, mcpserver, `{"sampling":{}}`)
+	messages := senderTest(mcpserver)
+
+	mcpserver.AddTool(types.NewContextTool("summarize", "summarize", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		params := types.NewCreateMessageParams([]types.SamplingMessage{*types.NewSamplingMessage(types.RoleUser, *types.NewOperationContent("text", "summarize this", "", nil))}, 100)
+		result, err := CreateMessage(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewToolResult([]types.OperationContent{result.Content}), nil
+	}))
+
+	table := []struct {
+		clientResponse   string
+		expectedResponse string
+	}{
+		{
+			`{"jsonrpc":"2.0","id":1,"result":{"role":"assistant","content":{"type":"text","text":"a summary"},"model":"model-1","stopReason":"endTurn"}}`,
+			`{"jsonrpc":"2.0","id":"call","result":{"content":[{"type":"text","text":"a summary"}]}}`,
+		},
+		{
+			`{"jsonrpc":"2.0","id":2,"error":{"code":-1,"message":"User rejected sampling request"}}`,
+			`{"jsonrpc":"2.0","id":"call","result":{"content":[{"type":"text","text":"client error -1: User rejected sampling request"}],"isError":true}}`,
+		},
+	}
+
+	for i, test := range table {
+		responses := make(chan string)
+		go func() {
+			response, _ := mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"summarize"}}`)
+			responses <- response
+		}()
+
+		expectedRequest := types.NewJSONRPCRequest(types.NewNumberId(int64(i+1)), SamplingCreateMessage, map[string]any{
+			"messages":  []any{map[string]any{"role": "user", "content": map[string]any{"type": "text", "text": "summarize this"}}},
+			"maxTokens": float64(100),
+		})
+		var actualRequest types.JSONRPCRequest
+		if err := json.Unmarshal([]byte(<-messages), &actualRequest); err != nil {
+			t.Fatalf("Expected nil but got %v", err)
+		}
+		if !reflect.DeepEqual(&actualRequest, expectedRequest) {
+			t.Errorf("Expected %#v but got %#v", expectedRequest, &actualRequest)
+		}
+
+		if response, _ := mcpserver.Handle(test.clientResponse); response != "" {
+			t.Errorf("Expected no response to the client response but got %s", response)
+		}
+
+		if actual := <-responses; actual != test.expectedResponse {
+			t.Errorf("Expected %s but got %s", test.expectedResponse, actual)
+		}
+	}
+
+	// a response to an unknown request is ignored, even with a null result
+	for _, clientResponse := range []string{
+		`{"jsonrpc":"2.0","id":99,"result":{}}`,
+		`{"jsonrpc":"2.0","id":99,"result":null}`,
+	} {
+		if response, _ := mcpserver.Handle(clientResponse); response != "" {
+			t.Errorf("Expected no response but got %s", response)
+		}
+	}
+}
+
+func TestCreateMessageWithTimeout(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeWithCapabilitiesTest(t, mcpserver, `{"sampling":{}}`)
+	messages := senderTest(mcpserver)
+
+	var err error
+	mcpserver.AddTool(types.NewContextTool("summarize", "summarize", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = CreateMessage(ctx, types.NewCreateMessageParams(nil, 100))
+		return types.NewToolResult(nil), nil
+	}))
+
+	mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"summarize"}}`)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %#v but got %#v", context.DeadlineExceeded, err)
+	}
+
+	<-messages
+	expected := `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1,"reason":"context deadline exceeded"}}`
+	if actual := <-messages; actual != expected {
+		t.Errorf("Expected %s but got %s", expected, actual)
+	}
+}
+
+func TestCreateMessageNotSupported(t *testing.T) {
+	mcpserver, teardown := setupTest(t)
+	defer teardown(t)
+	initializeWithCapabilitiesTest(t, mcpserver, `{}`)
+	senderTest(mcpserver)
+
+	var err error
+	mcpserver.AddTool(types.NewContextTool("summarize", "summarize", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		_, err = CreateMessage(ctx, types.NewCreateMessageParams(nil, 100))
+		return types.NewToolResult(nil), nil
+	}))
+
+	mcpserver.Handle(`{"jsonrpc":"2.0","id":"call","method":"tools/call","params":{"name":"summarize"}}`)
+
+	if !errors.Is(err, ErrSamplingNotSupported) {
+		t.Errorf("Expected %#v but got %#v", ErrSamplingNotSupported, err)
+	}
+
+	if _, err := CreateMessage(context.Background(), types.NewCreateMessageParams(nil, 100)); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Expected %#v but got %#v", ErrNoSession, err)
+	}
+}
@@ -0,0 +1,330 @@
+package gomcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// SessionState represents the lifecycle state of an MCP session.
+type SessionState int
+
+const (
+	// SessionUninitialized is the state of a new session, waiting for the initialize request.
+	SessionUninitialized SessionState = iota
+	// SessionInitializing is the state after the initialize response, waiting for notifications/initialized.
+	SessionInitializing
+	// SessionReady is the state of an initialized session, accepting every request.
+	SessionReady
+	// SessionShuttingDown is the state after the shutdown request, waiting for the exit notification.
+	SessionShuttingDown
+)
+
+var (
+	ErrSessionNotInitialized     = errors.New("session not initialized")
+	ErrSessionAlreadyInitialized = errors.New("session already initialized")
+	ErrSessionNotReady           = errors.New("session not ready: waiting for notifications/initialized")
+	ErrSessionShuttingDown       = errors.New("session is shutting down")
+	ErrSessionNoSender           = errors.New("session can't send messages to the client")
+	ErrSessionClosed             = errors.New("session closed")
+)
+
+// DefaultRequestTimeout is the maximum time the server waits for the response to a request
+// sent to the client, when the context of the request has no deadline.
+const DefaultRequestTimeout = 60 * time.Second
+
+// Sender delivers a message sent by the server to the client of a session.
+type Sender func(message string) error
+
+// String returns the name of the state.
+func (s SessionState) String() string {
+	switch s {
+	case SessionUninitialized:
+		return "uninitialized"
+	case SessionInitializing:
+		return "initializing"
+	case SessionReady:
+		return "ready"
+	case SessionShuttingDown:
+		return "shutting down"
+	default:
+		return "unknown"
+	}
+}
+
+// Session holds the state of an MCP session between the server and a client.
+type Session struct {
+	mu                 sync.RWMutex
+	state              SessionState
+	protocolVersion    string
+	clientInfo         types.ClientInfo
+	clientCapabilities types.ClientCapabilities
+
+	// ctx is the parent of the contexts of the requests, cancelled when the session is closed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// inFlight holds the cancel functions of the requests in progress, by id
+	inFlight map[string]context.CancelCauseFunc
+
+	sender Sender
+
+	// pending holds the channels waiting for the responses to the requests sent to the client, by id
+	pending map[string]chan *types.JSONRPCResponse
+	nextId  int64
+
+	// roots caches the roots of the client, rootsVersion is incremented when they change
+	roots        []types.Root
+	rootsCached  bool
+	rootsVersion int
+}
+
+// ClientError is the error returned when the client answers a request of the server with a JSON-RPC error.
+type ClientError struct {
+	Code    int
+	Message string
+	Data    any
+}
+
+// Error returns the code and the message of the error.
+func (e *ClientError) Error() string {
+	return fmt.Sprintf("client error %d: %s", e.Code, e.Message)
+}
+
+// newSession creates a new uninitialized Session.
+func newSession() *Session {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Session{
+		ctx:      ctx,
+		cancel:   cancel,
+		inFlight: make(map[string]context.CancelCauseFunc),
+		pending:  make(map[string]chan *types.JSONRPCResponse),
+	}
+}
+
+// State returns the current lifecycle state of the session.
+func (s *Session) State() SessionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state
+}
+
+// ProtocolVersion returns the protocol version negotiated on initialize.
+func (s *Session) ProtocolVersion() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.protocolVersion
+}
+
+// ClientInfo returns the information sent by the client on initialize.
+func (s *Session) ClientInfo() types.ClientInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientInfo
+}
+
+// ClientCapabilities returns the capabilities advertised by the client on initialize.
+func (s *Session) ClientCapabilities() types.ClientCapabilities {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientCapabilities
+}
+
+// Done returns a channel that is closed when the session is terminated, e.g. by the exit notification.
+func (s *Session) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// SetSender sets how the messages sent by the server to the client are delivered.
+// It is called by the transport serving the session.
+func (s *Session) SetSender(sender Sender) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sender = sender
+}
+
+// Notify sends a notification with the given method and params to the client.
+func (s *Session) Notify(method string, params any) error {
+	return s.notify(nil, method, params)
+}
+
+// notify sends a notification through sender, or the sender of the session when nil.
+func (s *Session) notify(sender Sender, method string, params any) error {
+	return s.send(sender, types.NewJSONRPCNotification(method, params))
+}
+
+// send marshals message and sends it through sender, or the sender of the session when nil.
+func (s *Session) send(sender Sender, message any) error {
+	if sender == nil {
+		s.mu.RLock()
+		sender = s.sender
+		s.mu.RUnlock()
+	}
+
+	if sender == nil {
+		return ErrSessionNoSender
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	return sender(string(data))
+}
+
+// request sends a request with the given method and params to the client and decodes the result of
+// its response into result. It waits for the response until ctx is done, DefaultRequestTimeout when
+// ctx has no deadline, or the session is closed; when ctx is done the request is cancelled.
+func (s *Session) request(ctx context.Context, method string, params any, result any) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultRequestTimeout)
+		defer cancel()
+	}
+
+	s.mu.Lock()
+	s.nextId++
+	id := types.NewNumberId(s.nextId)
+	responses := make(chan *types.JSONRPCResponse, 1)
+	s.pending[id.String()] = responses
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id.String())
+		s.mu.Unlock()
+	}()
+
+	// the request goes through the sender of the request being handled, if any
+	sender := senderFromContext(ctx)
+	if err := s.send(sender, types.NewJSONRPCRequest(id, method, params)); err != nil {
+		return err
+	}
+
+	select {
+	case response := <-responses:
+		if response.Error != nil {
+			return &ClientError{Code: response.Error.Code, Message: response.Error.Message, Data: response.Error.Data}
+		}
+
+		resultBytes, _ := json.Marshal(response.Result)
+		if err := json.Unmarshal(resultBytes, result); err != nil {
+			return fmt.Errorf("invalid result of %s: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		s.notify(sender, Cancelled, types.NewCancelledParams(id, context.Cause(ctx).Error()))
+		return ctx.Err()
+	case <-s.ctx.Done():
+		return ErrSessionClosed
+	}
+}
+
+// handleResponse delivers a response sent by the client to the request waiting for it,
+// reporting whether the request was found.
+func (s *Session) handleResponse(response *types.JSONRPCResponse) bool {
+	s.mu.RLock()
+	responses, ok := s.pending[response.Id.String()]
+	s.mu.RUnlock()
+
+	if ok {
+		// a duplicated response is dropped
+		select {
+		case responses <- response:
+		default:
+		}
+	}
+	return ok
+}
+
+// checkRequest verifies that a request with the given method is allowed in the current state.
+func (s *Session) checkRequest(method string) error {
+	state := s.State()
+
+	switch {
+	case state == SessionShuttingDown:
+		return ErrSessionShuttingDown
+	case method == Initialize && state != SessionUninitialized:
+		return ErrSessionAlreadyInitialized
+	case method == Initialize:
+		return nil
+	case state == SessionUninitialized:
+		return ErrSessionNotInitialized
+	case state == SessionInitializing:
+		return ErrSessionNotReady
+	default:
+		return nil
+	}
+}
+
+// initialize records the client parameters and moves the session to SessionInitializing.
+func (s *Session) initialize(protocolVersion string, params *types.InitializeParams) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = SessionInitializing
+	s.protocolVersion = protocolVersion
+	s.clientInfo = params.ClientInfo
+	s.clientCapabilities = params.Capabilities
+}
+
+// transition moves the session from one state to another, reporting whether it was in the from state.
+func (s *Session) transition(from, to SessionState) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state != from {
+		return false
+	}
+
+	s.state = to
+	return true
+}
+
+// track records the cancel function of the request in progress with the given id.
+func (s *Session) track(id string, cancel context.CancelCauseFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight[id] = cancel
+}
+
+// untrack forgets the request with the given id, once it is completed.
+func (s *Session) untrack(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, id)
+}
+
+// cancelRequest cancels the context of the request in progress with the given id,
+// reporting whether the request was found.
+func (s *Session) cancelRequest(id string, cause error) bool {
+	s.mu.RLock()
+	cancel, ok := s.inFlight[id]
+	s.mu.RUnlock()
+
+	if ok {
+		cancel(cause)
+	}
+	return ok
+}
+
+// Close terminates the session, as the exit notification does, e.g. when an HTTP client ends it.
+func (s *Session) Close() {
+	s.close()
+}
+
+// close marks the session as terminated, releasing whoever waits on Done
+// and cancelling the requests in progress.
+func (s *Session) close() {
+	s.mu.Lock()
+	s.state = SessionShuttingDown
+	s.mu.Unlock()
+
+	s.cancel()
+}
@@ -0,0 +1,53 @@
+package gomcp
+
+import (
+	"errors"
+
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// ToolError is an error returned by a tool handler to report a failure to the model, as a result
+// with isError set. It is the default for every error, ToolError lets the handler choose the content.
+type ToolError struct {
+	Message string
+	Content []types.OperationContent
+}
+
+// NewToolError creates a new ToolError with the given message and content.
+// When no content is given, the message is sent as text content.
+func NewToolError(message string, content ...types.OperationContent) *ToolError {
+	return &ToolError{Message: message, Content: content}
+}
+
+// Error returns the message of the error.
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// ProtocolError is an error returned by a tool handler to answer with a JSON-RPC error
+// instead of a result with isError set, e.g. when the request itself is invalid.
+type ProtocolError struct {
+	Code    int
+	Message string
+	Data    any
+}
+
+// NewProtocolError creates a new ProtocolError with the given JSON-RPC error code, message and data.
+func NewProtocolError(code int, message string, data any) *ProtocolError {
+	return &ProtocolError{Code: code, Message: message, Data: data}
+}
+
+// Error returns the message of the error.
+func (e *ProtocolError) Error() string {
+	return e.Message
+}
+
+// toolErrorResult converts the error returned by a tool handler into a result with isError set.
+func toolErrorResult(err error) *types.ToolResult {
+	var toolErr *ToolError
+	if errors.As(err, &toolErr) && len(toolErr.Content) > 0 {
+		return types.NewToolErrorResult(toolErr.Content)
+	}
+
+	return types.NewToolErrorResult([]types.OperationContent{*types.NewOperationContent("text", err.Error(), "", nil)})
+}
@@ -1,6 +1,9 @@
 package gomcp
 
+import "context"
+
 type Transport interface {
 	SetMCPServer(mcpserver *MCPServer)
-	Start()
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
 }
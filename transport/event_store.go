@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// DefaultEventBufferSize is the default number of events kept for each session by the MemoryEventStore.
+const DefaultEventBufferSize = 100
+
+// ErrEventNotFound is returned when replaying from an event that is unknown or no longer stored.
+var ErrEventNotFound = errors.New("event not found")
+
+// Event is a message sent on an SSE stream, with its id.
+type Event struct {
+	Id      string
+	Message string
+}
+
+// EventStore stores the events sent on the SSE streams of the HttpTransport, so that a client
+// can resume a stream after a disconnection with the Last-Event-ID header.
+type EventStore interface {
+	// Store stores message as the next event of stream in session, returning the id of the event,
+	// unique in the session.
+	Store(session, stream, message string) (string, error)
+	// Replay returns the stream of the event lastEventId and the events sent on it after that event, in order.
+	Replay(session, lastEventId string) (string, []Event, error)
+	// Remove removes the events of session, once it is terminated.
+	Remove(session string)
+}
+
+// MemoryEventStore is an EventStore keeping in memory the last events of each session.
+type MemoryEventStore struct {
+	mu       sync.Mutex
+	size     int
+	sessions map[string]*eventBuffer
+}
+
+// eventBuffer holds the last events of a session.
+type eventBuffer struct {
+	events []streamEvent
+	nextId int64
+}
+
+type streamEvent struct {
+	Event
+	stream string
+}
+
+// NewMemoryEventStore creates a MemoryEventStore keeping the last size events of each session.
+// A size lower than 1 keeps no events, so the streams can't be resumed.
+func NewMemoryEventStore(size int) *MemoryEventStore {
+	return &MemoryEventStore{size: max(size, 0), sessions: make(map[string]*eventBuffer)}
+}
+
+// Store implements EventStore, dropping the oldest event of the session when its buffer is full.
+func (m *MemoryEventStore) Store(session, stream, message string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buffer, ok := m.sessions[session]
+	if !ok {
+		buffer = &eventBuffer{}
+		m.sessions[session] = buffer
+	}
+
+	buffer.nextId++
+	id := strconv.FormatInt(buffer.nextId, 10)
+
+	buffer.events = append(buffer.events, streamEvent{Event{id, message}, stream})
+	if len(buffer.events) > m.size {
+		buffer.events = slices.Delete(buffer.events, 0, len(buffer.events)-m.size)
+	}
+
+	return id, nil
+}
+
+// Replay implements EventStore.
+func (m *MemoryEventStore) Replay(session, lastEventId string) (string, []Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buffer, ok := m.sessions[session]
+	if !ok {
+		return "", nil, ErrEventNotFound
+	}
+
+	i := slices.IndexFunc(buffer.events, func(e streamEvent) bool { return e.Id == lastEventId })
+	if i < 0 {
+		return "", nil, ErrEventNotFound
+	}
+
+	stream := buffer.events[i].stream
+	var events []Event
+	for _, e := range buffer.events[i+1:] {
+		if e.stream == stream {
+			events = append(events, e.Event)
+		}
+	}
+
+	return stream, events, nil
+}
+
+// Remove implements EventStore.
+func (m *MemoryEventStore) Remove(session string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, session)
+}
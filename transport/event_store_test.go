@@ -0,0 +1,118 @@
+package transport
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryEventStore(t *testing.T) {
+	store := NewMemoryEventStore(3)
+
+	for _, e := range []struct{ session, stream, message string }{
+		{"s1", "a", "a1"},
+		{"s1", "b", "b1"},
+		{"s2", "a", "other session"},
+		{"s1", "a", "a2"},
+		{"s1", "a", "a3"},
+		{"s1", "b", "b2"},
+	} {
+		store.Store(e.session, e.stream, e.message)
+	}
+
+	table := []struct {
+		session        string
+		lastEventId    string
+		expectedStream string
+		expectedEvents []Event
+		expectedErr    error
+	}{
+		{
+			// the first events of s1 were dropped
+			"s1",
+			"2",
+			"",
+			nil,
+			ErrEventNotFound,
+		},
+		{
+			"s1",
+			"3",
+			"a",
+			[]Event{{"4", "a3"}},
+			nil,
+		},
+		{
+			"s1",
+			"4",
+			"a",
+			nil,
+			nil,
+		},
+		{
+			"s1",
+			"5",
+			"b",
+			nil,
+			nil,
+		},
+		{
+			"s2",
+			"1",
+			"a",
+			nil,
+			nil,
+		},
+		{
+			"unknown",
+			"1",
+			"",
+			nil,
+			ErrEventNotFound,
+		},
+	}
+
+	for _, test := range table {
+		stream, events, err := store.Replay(test.session, test.lastEventId)
+
+		if !errors.Is(err, test.expectedErr) {
+			t.Errorf("Expected %#v but got %#v", test.expectedErr, err)
+		}
+
+		if stream != test.expectedStream {
+			t.Errorf("Expected %s but got %s", test.expectedStream, stream)
+		}
+
+		if !reflect.DeepEqual(events, test.expectedEvents) {
+			t.Errorf("Expected %#v but got %#v", test.expectedEvents, events)
+		}
+	}
+
+	store.Remove("s1")
+	if _, _, err := store.Replay("s1", "5"); !errors.Is(err, ErrEventNotFound) {
+		t.Errorf("Expected %#v but got %#v", ErrEventNotFound, err)
+	}
+}
+
+func TestMemoryEventStoreWithoutEvents(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		store := NewMemoryEventStore(size)
+
+		var ids []string
+		for _, message := range []string{"a1", "a2"} {
+			id, err := store.Store("s1", "a", message)
+			if err != nil {
+				t.Errorf("Expected nil but got %#v", err)
+			}
+			ids = append(ids, id)
+		}
+
+		if !reflect.DeepEqual(ids, []string{"1", "2"}) {
+			t.Errorf("Expected %#v but got %#v", []string{"1", "2"}, ids)
+		}
+
+		if _, _, err := store.Replay("s1", "1"); !errors.Is(err, ErrEventNotFound) {
+			t.Errorf("Expected %#v but got %#v", ErrEventNotFound, err)
+		}
+	}
+}
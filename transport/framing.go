@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Framing is the way the messages are delimited on the streams of the StdioTransport.
+type Framing int
+
+const (
+	// FramingNewline delimits the messages with a newline, as in the stdio transport of MCP.
+	FramingNewline Framing = iota
+	// FramingContentLength precedes each message with a Content-Length header and a blank line,
+	// as in the Language Server Protocol.
+	FramingContentLength
+)
+
+// DefaultMaxMessageSize is the default maximum size in bytes of the messages read by the StdioTransport.
+const DefaultMaxMessageSize = 32 << 20
+
+var (
+	ErrMissingContentLength = errors.New("missing Content-Length header")
+	ErrMessageTooLarge      = errors.New("message larger than the maximum size")
+)
+
+// messageReader reads the messages delimited with a framing from a stream. The size of the messages,
+// without their delimiter, is limited by maxSize, unless lower than 1.
+type messageReader struct {
+	r       *bufio.Reader
+	framing Framing
+	maxSize int
+}
+
+func newMessageReader(r io.Reader, framing Framing, maxSize int) *messageReader {
+	return &messageReader{r: bufio.NewReader(r), framing: framing, maxSize: maxSize}
+}
+
+// ReadMessage reads the next message, returning io.EOF at the end of the stream.
+func (m *messageReader) ReadMessage() (string, error) {
+	if m.framing == FramingContentLength {
+		return m.readContentLength()
+	}
+
+	return m.readLine()
+}
+
+// readLine reads a newline delimited message, failing as soon as it grows beyond maxSize, so that
+// an endless line is not kept in memory.
+func (m *messageReader) readLine() (string, error) {
+	var line []byte
+	for {
+		chunk, err := m.r.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		if m.maxSize > 0 && len(bytes.TrimSuffix(line, []byte("\n"))) > m.maxSize {
+			return "", fmt.Errorf("%w: line longer than %d bytes", ErrMessageTooLarge, m.maxSize)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if err == io.EOF && len(line) > 0 {
+			// the last message may not end with a newline
+			return string(line), nil
+		}
+		return string(line), err
+	}
+}
+
+func (m *messageReader) readContentLength() (string, error) {
+	length := -1
+	headers := false
+
+	for {
+		line, err := m.r.ReadString('\n')
+		if err == io.EOF && (headers || line != "") {
+			return "", io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return "", err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if !headers {
+				continue
+			}
+			// the blank line ends the headers
+			break
+		}
+		headers = true
+
+		// the other headers, e.g. Content-Type, are ignored
+		name, value, _ := strings.Cut(line, ":")
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return "", fmt.Errorf("invalid Content-Length header %q", value)
+			}
+		}
+	}
+
+	if length < 0 {
+		return "", ErrMissingContentLength
+	}
+	if m.maxSize > 0 && length > m.maxSize {
+		return "", fmt.Errorf("%w: Content-Length %d", ErrMessageTooLarge, length)
+	}
+
+	// the body grows as it is read, so a wrong length doesn't allocate it upfront
+	var body strings.Builder
+	if _, err := io.CopyN(&body, m.r, int64(length)); err != nil {
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return body.String(), nil
+}
+
+// messageWriter writes messages delimited with a framing, serializing the concurrent writes.
+type messageWriter struct {
+	mu      sync.Mutex
+	w       *bufio.Writer
+	framing Framing
+}
+
+func newMessageWriter(w io.Writer, framing Framing) *messageWriter {
+	return &messageWriter{w: bufio.NewWriter(w), framing: framing}
+}
+
+// WriteMessage writes message with its framing and flushes it. Empty messages are skipped.
+func (m *messageWriter) WriteMessage(message string) error {
+	if message == "" {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var err error
+	if m.framing == FramingContentLength {
+		_, err = fmt.Fprintf(m.w, "Content-Length: %d\r\n\r\n%s", len(message), message)
+	} else {
+		_, err = fmt.Fprintln(m.w, message)
+	}
+
+	if err != nil {
+		return err
+	}
+	return m.w.Flush()
+}
@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessageReader(t *testing.T) {
+	var tests = []struct {
+		framing  Framing
+		input    string
+		expected []string
+		err      error
+	}{
+		{FramingNewline, "{}\n{\"a\":1}\n", []string{"{}\n", "{\"a\":1}\n"}, io.EOF},
+		{FramingNewline, "{}\n{\"a\":1}", []string{"{}\n", "{\"a\":1}"}, io.EOF},
+		{FramingNewline, "{\"a\":\"bc\"}\n{\"a\":\"abc\"}\n", []string{"{\"a\":\"bc\"}\n"}, ErrMessageTooLarge},
+		{FramingNewline, "{\"a\":\"abc\"}", nil, ErrMessageTooLarge},
+		{FramingContentLength, "Content-Length: 2\r\n\r\n{}content-length:7\r\n\r\n{\"a\":1}", []string{"{}", "{\"a\":1}"}, io.EOF},
+		{FramingContentLength, "Content-Type: application/json\r\n\r\n{}", nil, ErrMissingContentLength},
+		{FramingContentLength, "Content-Length: 7\r\n\r\n{}", nil, io.ErrUnexpectedEOF},
+		{FramingContentLength, "Content-Length: 2\r\n", nil, io.ErrUnexpectedEOF},
+		{FramingContentLength, "Content-Length: 11\r\n\r\n{\"a\":\"abc\"}", nil, ErrMessageTooLarge},
+		{FramingContentLength, "Content-Length: 9223372036854775807\r\n\r\n{}", nil, ErrMessageTooLarge},
+	}
+
+	for _, test := range tests {
+		reader := newMessageReader(strings.NewReader(test.input), test.framing, 10)
+
+		var messages []string
+		var err error
+		for {
+			var message string
+			if message, err = reader.ReadMessage(); err != nil {
+				break
+			}
+			messages = append(messages, message)
+		}
+
+		if strings.Join(messages, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("Expected %#v but got %#v", test.expected, messages)
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("Expected %#v but got %#v", test.err, err)
+		}
+	}
+
+	for _, length := range []string{"two", "-1", "99999999999999999999"} {
+		reader := newMessageReader(strings.NewReader("Content-Length: "+length+"\r\n\r\n{}"), FramingContentLength, 10)
+		if _, err := reader.ReadMessage(); err == nil {
+			t.Errorf("Expected an error for the invalid Content-Length %s", length)
+		}
+	}
+
+	// with no limit, a wrong length is read until the end of the stream without allocating it
+	reader := newMessageReader(strings.NewReader("Content-Length: 9223372036854775807\r\n\r\n{}"), FramingContentLength, 0)
+	if _, err := reader.ReadMessage(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected %#v but got %#v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestMessageWriter(t *testing.T) {
+	var tests = []struct {
+		framing  Framing
+		expected string
+	}{
+		{FramingNewline, "{}\n{\"a\":1}\n"},
+		{FramingContentLength, "Content-Length: 2\r\n\r\n{}Content-Length: 7\r\n\r\n{\"a\":1}"},
+	}
+
+	for _, test := range tests {
+		out := &bytes.Buffer{}
+		writer := newMessageWriter(out, test.framing)
+		for _, message := range []string{"{}", "", "{\"a\":1}"} {
+			if err := writer.WriteMessage(message); err != nil {
+				t.Errorf("Expected no error but got %#v", err)
+			}
+		}
+
+		if out.String() != test.expected {
+			t.Errorf("Expected %#v but got %#v", test.expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,690 @@
+package transport
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mcpunzo/gomcp"
+)
+
+// DefaultHttpPath is the default path of the endpoint served by the HttpTransport.
+const DefaultHttpPath = "/mcp"
+
+// DefaultSessionTimeout is the default time after which a session of the HttpTransport with no
+// request of its client in progress is terminated.
+const DefaultSessionTimeout = 30 * time.Minute
+
+const (
+	// SessionIdHeader is the header carrying the id of the session, assigned on initialize.
+	SessionIdHeader = "Mcp-Session-Id"
+	// ProtocolVersionHeader is the header carrying the protocol version negotiated on initialize.
+	ProtocolVersionHeader = "Mcp-Protocol-Version"
+	// LastEventIdHeader is the header carrying the id of the last event received by a client resuming a stream.
+	LastEventIdHeader = "Last-Event-ID"
+)
+
+// HttpTransport implements the Streamable HTTP transport of MCP, on the DefaultHttpPath endpoint
+// or mounted on a router of the application through Handler.
+// Each client has its own session, identified by the Mcp-Session-Id header:
+//
+//	POST    sends messages; requests are answered with a JSON body, or with an SSE stream
+//	        when the server sends messages to the client (progress, sampling...) before the response
+//	GET     opens an SSE stream for the messages of the server not related to a request,
+//	        or resumes a stream with the Last-Event-ID header
+//	DELETE  ends the session
+//
+// The events of the SSE streams have ids and are kept in an EventStore, so a client that lost the
+// connection can resume a stream and receive the events it missed.
+type HttpTransport struct {
+	mgp     *gomcp.MCPServer
+	port    int
+	path    string
+	server  *http.Server
+	store   EventStore
+	timeout time.Duration
+	origins []string // the origins allowed besides the localhost ones
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+	running  *http.Server   // the server started by Start
+	stopping bool           // set by Stop, the following requests are refused
+	stopped  chan struct{}  // closed when Stop returns
+	requests sync.WaitGroup // the HTTP requests in progress
+}
+
+// httpSession is a session of the HttpTransport, with its SSE streams.
+type httpSession struct {
+	id      string
+	session *gomcp.Session
+	store   EventStore
+	timeout time.Duration
+
+	mu         sync.Mutex
+	standalone *sseStream            // the stream opened with GET, nil when not open
+	streams    map[string]*sseStream // the streams in progress, by id
+	active     int                   // the requests of the client in progress
+	idle       *time.Timer           // terminates the session once idle for the timeout
+}
+
+func NewHttpTransport(port int) *HttpTransport {
+	return &HttpTransport{
+		port:     port,
+		path:     DefaultHttpPath,
+		store:    NewMemoryEventStore(DefaultEventBufferSize),
+		timeout:  DefaultSessionTimeout,
+		sessions: make(map[string]*httpSession),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// WithEventStore sets the store of the events sent on the SSE streams, a MemoryEventStore
+// keeping DefaultEventBufferSize events per session by default.
+func (h *HttpTransport) WithEventStore(store EventStore) *HttpTransport {
+	h.store = store
+	return h
+}
+
+// WithSessionTimeout sets the time after which a session with no request of its client in progress,
+// open GET streams included, is terminated, DefaultSessionTimeout by default. A timeout lower than 1
+// means that the sessions are terminated only by the clients.
+func (h *HttpTransport) WithSessionTimeout(timeout time.Duration) *HttpTransport {
+	h.timeout = timeout
+	return h
+}
+
+// WithAllowedOrigins sets the origins allowed to send requests from a browser besides the localhost ones,
+// e.g. "https://app.example.com", to prevent DNS rebinding attacks; "*" allows any origin. Requests with
+// an Origin header not allowed are refused with 403 Forbidden. Requests without Origin header, from
+// clients other than browsers, are always accepted.
+func (h *HttpTransport) WithAllowedOrigins(origins ...string) *HttpTransport {
+	h.origins = origins
+	return h
+}
+
+// WithPath sets the path of the endpoint served by Start, DefaultHttpPath by default.
+func (h *HttpTransport) WithPath(path string) *HttpTransport {
+	h.path = path
+	return h
+}
+
+// WithServer sets the http.Server used by Start instead of one listening on the port of localhost, e.g. to
+// listen on all the interfaces or to set
+// its timeouts or TLS configuration. When the server has no Handler, it is set to serve the endpoint
+// on the path; otherwise the Handler of the transport is expected to be mounted on it.
+func (h *HttpTransport) WithServer(server *http.Server) *HttpTransport {
+	h.server = server
+	return h
+}
+
+// SetMCPServer sets the MCPServer for the HttpTransport.
+func (h *HttpTransport) SetMCPServer(mcpserver *gomcp.MCPServer) {
+	h.mgp = mcpserver
+}
+
+// Handler returns the http.Handler of the MCP endpoint, to mount it on the router of the application,
+// e.g. behind its middlewares, instead of calling Start.
+func (h *HttpTransport) Handler() http.Handler {
+	return http.HandlerFunc(h.handler)
+}
+
+// Start starts the HTTP server serving the endpoint, with TLS when the server has a TLS configuration.
+// When ctx is cancelled, the server is closed at once, terminating the sessions, and ctx.Err() is returned.
+// It returns nil when the server is shut down, once Stop completes, or the error that stopped it.
+func (h *HttpTransport) Start(ctx context.Context) error {
+	server := h.server
+	if server == nil {
+		// only local clients can connect, unless a server is set
+		server = &http.Server{Addr: fmt.Sprintf("localhost:%d", h.port)}
+	}
+
+	if server.Handler == nil {
+		mux := http.NewServeMux()
+		mux.Handle(h.path, h.Handler())
+		server.Handler = mux
+	}
+
+	h.mu.Lock()
+	if h.stopping {
+		h.mu.Unlock()
+		return nil
+	}
+	h.running = server
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			h.closeSessions()
+			server.Close()
+		case <-done:
+		}
+	}()
+
+	var err error
+	log.Printf("Server started and listening on %s", server.Addr)
+	if server.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	h.mu.Lock()
+	stopping := h.stopping
+	h.mu.Unlock()
+
+	if stopping {
+		// the requests in progress are drained
+		<-h.stopped
+	}
+
+	log.Print("Server stopped")
+	return nil
+}
+
+// Stop refuses the new requests and waits for the requests in progress to complete, then terminates
+// the sessions and shuts down the server started by Start, if any. The streams opened with GET are closed.
+// When ctx is done before the requests complete, they are cancelled and ctx.Err() is returned.
+func (h *HttpTransport) Stop(ctx context.Context) error {
+	h.mu.Lock()
+	if h.stopping {
+		h.mu.Unlock()
+		<-h.stopped
+		return nil
+	}
+	h.stopping = true
+	server := h.running
+	h.mu.Unlock()
+	defer close(h.stopped)
+
+	// the standalone streams end only with the sessions
+	for _, hs := range h.sessionList() {
+		hs.setStandalone(nil)
+	}
+
+	err := waitContext(ctx, &h.requests)
+	h.closeSessions()
+
+	if server != nil {
+		if err != nil {
+			server.Close()
+		} else {
+			err = server.Shutdown(ctx)
+		}
+	}
+	return err
+}
+
+// sessionList returns the sessions of the transport.
+func (h *HttpTransport) sessionList() []*httpSession {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return slices.Collect(maps.Values(h.sessions))
+}
+
+// closeSessions terminates the sessions, cancelling their requests in progress.
+func (h *HttpTransport) closeSessions() {
+	for _, hs := range h.sessionList() {
+		hs.session.Close()
+	}
+}
+
+func (h *HttpTransport) handler(w http.ResponseWriter, r *http.Request) {
+	if !h.allowedOrigin(r.Header.Get("Origin")) {
+		http.Error(w, "Forbidden: origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	h.mu.Lock()
+	if h.stopping {
+		h.mu.Unlock()
+		http.Error(w, "Service Unavailable: the server is stopping", http.StatusServiceUnavailable)
+		return
+	}
+	h.requests.Add(1)
+	h.mu.Unlock()
+	defer h.requests.Done()
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleGet(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *HttpTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := io.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		http.Error(w, "Error reading the request body", http.StatusBadRequest)
+		return
+	}
+
+	hasRequests, initialize := classifyMessages(bodyBytes)
+
+	var hs *httpSession
+	created := initialize && hasRequests && r.Header.Get(SessionIdHeader) == ""
+	if created {
+		hs = h.newSession()
+	} else if hs = h.lookupSession(w, r); hs == nil {
+		return
+	}
+	defer hs.release()
+
+	bodyString := string(bodyBytes)
+
+	if !hasRequests {
+		response, _ := h.mgp.HandleContext(r.Context(), hs.session, bodyString)
+		if response == "" {
+			// notifications and responses are not answered
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		writeJSON(w, response)
+		return
+	}
+
+	// the disconnection of the client doesn't cancel the requests, the client can resume the stream
+	ctx := context.WithoutCancel(r.Context())
+
+	stream := &responseStream{w: w, session: hs, acceptsSSE: acceptsSSE(r)}
+	response, err := h.mgp.HandleContext(gomcp.ContextWithSender(ctx, stream.send), hs.session, bodyString)
+	log.Printf("Response: %s", response)
+
+	if created {
+		// the session is kept only when initialize succeeds, so failed attempts don't pile up
+		if err != nil || hs.session.State() == gomcp.SessionUninitialized {
+			hs.session.Close()
+		} else {
+			h.addSession(w, hs)
+		}
+	}
+
+	if err != nil {
+		stream.fail(http.StatusInternalServerError)
+		return
+	}
+
+	stream.finish(response)
+}
+
+func (h *HttpTransport) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !acceptsSSE(r) {
+		http.Error(w, "Not Acceptable: the client must accept text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+
+	hs := h.lookupSession(w, r)
+	if hs == nil {
+		return
+	}
+	defer hs.release()
+
+	if lastEventId := r.Header.Get(LastEventIdHeader); lastEventId != "" {
+		hs.resumeStream(w, r, lastEventId)
+		return
+	}
+
+	startSSE(w)
+	stream := hs.newStream()
+	hs.setStandalone(stream)
+
+	// the stream stays open after the disconnection, until it is replaced by a new one
+	hs.serveStream(r, stream, stream.attach(w))
+}
+
+func (h *HttpTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	hs := h.lookupSession(w, r)
+	if hs == nil {
+		return
+	}
+	defer hs.release()
+
+	hs.session.Close()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowedOrigin reports whether a request with the given Origin header is accepted.
+func (h *HttpTransport) allowedOrigin(origin string) bool {
+	if origin == "" {
+		return true
+	}
+
+	if slices.Contains(h.origins, "*") || slices.Contains(h.origins, origin) {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// newSession creates a new session for an initialize request, with a request of its client in progress.
+// The session is not known to the transport until addSession.
+func (h *HttpTransport) newSession() *httpSession {
+	hs := &httpSession{id: rand.Text(), session: h.mgp.NewSession(), store: h.store, timeout: h.timeout, streams: make(map[string]*sseStream)}
+	hs.session.SetSender(hs.send)
+	hs.acquire()
+	return hs
+}
+
+// addSession adds a session once initialized, setting its id in the response headers.
+func (h *HttpTransport) addSession(w http.ResponseWriter, hs *httpSession) {
+	h.mu.Lock()
+	h.sessions[hs.id] = hs
+	h.mu.Unlock()
+
+	// forget the session when it is terminated
+	go func() {
+		<-hs.session.Done()
+		h.mu.Lock()
+		delete(h.sessions, hs.id)
+		h.mu.Unlock()
+		h.store.Remove(hs.id)
+	}()
+
+	w.Header().Set(SessionIdHeader, hs.id)
+}
+
+// lookupSession returns the session of the request, marking a request of its client in progress until
+// release, or writes the error response and returns nil when the session id is missing, unknown or the
+// protocol version doesn't match.
+func (h *HttpTransport) lookupSession(w http.ResponseWriter, r *http.Request) *httpSession {
+	id := r.Header.Get(SessionIdHeader)
+	if id == "" {
+		http.Error(w, "Bad Request: missing "+SessionIdHeader+" header", http.StatusBadRequest)
+		return nil
+	}
+
+	h.mu.Lock()
+	hs, ok := h.sessions[id]
+	h.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "Session Not Found", http.StatusNotFound)
+		return nil
+	}
+
+	version := r.Header.Get(ProtocolVersionHeader)
+	if negotiated := hs.session.ProtocolVersion(); version != "" && negotiated != "" && version != negotiated {
+		http.Error(w, "Bad Request: unsupported protocol version "+version, http.StatusBadRequest)
+		return nil
+	}
+
+	hs.acquire()
+	return hs
+}
+
+// acquire marks a request of the client in progress, the session doesn't expire meanwhile.
+func (hs *httpSession) acquire() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	hs.active++
+	if hs.idle != nil {
+		hs.idle.Stop()
+	}
+}
+
+// release ends a request of the client, the session expires when it stays idle for the timeout.
+func (hs *httpSession) release() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	hs.active--
+	if hs.active > 0 || hs.timeout <= 0 {
+		return
+	}
+
+	select {
+	case <-hs.session.Done():
+		// terminated, e.g. by DELETE
+		return
+	default:
+	}
+
+	if hs.idle == nil {
+		hs.idle = time.AfterFunc(hs.timeout, hs.expire)
+	} else {
+		hs.idle.Reset(hs.timeout)
+	}
+}
+
+// expire terminates the session, unless a request of the client came in the meantime.
+func (hs *httpSession) expire() {
+	hs.mu.Lock()
+	active := hs.active
+	hs.mu.Unlock()
+
+	select {
+	case <-hs.session.Done():
+		return
+	default:
+	}
+
+	if active == 0 {
+		log.Printf("Session %s expired", hs.id)
+		hs.session.Close()
+	}
+}
+
+// send sends message on the standalone stream of the session.
+func (hs *httpSession) send(message string) error {
+	hs.mu.Lock()
+	stream := hs.standalone
+	hs.mu.Unlock()
+
+	if stream == nil {
+		return gomcp.ErrSessionNoSender
+	}
+	return stream.send(message)
+}
+
+// newStream creates a new stream of the session.
+func (hs *httpSession) newStream() *sseStream {
+	stream := newSSEStream(rand.Text(), hs.id, hs.store)
+
+	hs.mu.Lock()
+	hs.streams[stream.id] = stream
+	hs.mu.Unlock()
+
+	return stream
+}
+
+// closeStream ends stream, its events can still be replayed.
+func (hs *httpSession) closeStream(stream *sseStream) {
+	hs.mu.Lock()
+	delete(hs.streams, stream.id)
+	hs.mu.Unlock()
+
+	stream.close()
+}
+
+// setStandalone sets the standalone stream of the session, closing the previous one.
+func (hs *httpSession) setStandalone(stream *sseStream) {
+	hs.mu.Lock()
+	previous := hs.standalone
+	hs.standalone = stream
+	hs.mu.Unlock()
+
+	if previous != nil {
+		hs.closeStream(previous)
+	}
+}
+
+// resumeStream replays the events sent after lastEventId on its stream and, if the stream is still
+// in progress, serves the following events.
+func (hs *httpSession) resumeStream(w http.ResponseWriter, r *http.Request, lastEventId string) {
+	streamId, events, err := hs.store.Replay(hs.id, lastEventId)
+	if err != nil {
+		http.Error(w, "Event Not Found", http.StatusNotFound)
+		return
+	}
+
+	hs.mu.Lock()
+	stream := hs.streams[streamId]
+	hs.mu.Unlock()
+
+	startSSE(w)
+
+	if stream == nil {
+		// the stream ended
+		for _, event := range events {
+			if writeEvent(w, event) != nil {
+				return
+			}
+		}
+		return
+	}
+
+	detached, err := stream.resume(w, lastEventId)
+	if err != nil {
+		return
+	}
+	hs.serveStream(r, stream, detached)
+}
+
+// serveStream waits until the stream ends, the client disconnects or a new connection replaces it.
+func (hs *httpSession) serveStream(r *http.Request, stream *sseStream, detached <-chan struct{}) {
+	defer stream.detach(detached)
+
+	select {
+	case <-r.Context().Done():
+	case <-hs.session.Done():
+	case <-stream.done:
+	case <-detached:
+	}
+}
+
+// responseStream answers a POST with requests: with a JSON body, or with an SSE stream when the server
+// sends messages to the client before the response is ready and the client accepts text/event-stream.
+// When the client doesn't accept it, the messages go on the standalone stream of the session.
+type responseStream struct {
+	w          http.ResponseWriter
+	session    *httpSession
+	acceptsSSE bool
+
+	mu       sync.Mutex
+	stream   *sseStream // set once streaming
+	finished bool
+}
+
+// send sends message to the client before the response, switching to an SSE stream.
+func (s *responseStream) send(message string) error {
+	s.mu.Lock()
+	if s.finished || !s.acceptsSSE {
+		s.mu.Unlock()
+		return s.session.send(message)
+	}
+
+	if s.stream == nil {
+		startSSE(s.w)
+		s.stream = s.session.newStream()
+		s.stream.attach(s.w)
+	}
+	stream := s.stream
+	s.mu.Unlock()
+
+	return stream.send(message)
+}
+
+// finish writes the response, as the last event of the stream when streaming.
+func (s *responseStream) finish(response string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = true
+
+	if s.stream != nil {
+		if response != "" {
+			s.stream.send(response)
+		}
+		s.session.closeStream(s.stream)
+		return
+	}
+
+	if response == "" {
+		// the requests were cancelled
+		s.w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	writeJSON(s.w, response)
+}
+
+// fail answers with the given status, unless the stream already started.
+func (s *responseStream) fail(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finished = true
+
+	if s.stream != nil {
+		s.session.closeStream(s.stream)
+		return
+	}
+
+	http.Error(s.w, http.StatusText(status), status)
+}
+
+func writeJSON(w http.ResponseWriter, response string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, response)
+}
+
+func acceptsSSE(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// classifyMessages reports whether body, a JSON-RPC message or batch, contains requests,
+// and whether it is an initialize request. Invalid bodies are handled as requests.
+func classifyMessages(body []byte) (hasRequests bool, initialize bool) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		method, _, request := requestMethod(string(body))
+		return request, method == gomcp.Initialize
+	}
+
+	for _, message := range batch {
+		if _, _, request := requestMethod(string(message)); request {
+			return true, false
+		}
+	}
+	return len(batch) == 0, false
+}
@@ -0,0 +1,638 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcpunzo/gomcp"
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// startHttpTest starts an HttpTransport serving mcpserver on a test server.
+func startHttpTest(t *testing.T, mcpserver *gomcp.MCPServer) *httptest.Server {
+	transport := NewHttpTransport(0)
+	mcpserver.WithTransport(transport)
+
+	server := httptest.NewServer(transport.Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// requestHttpTest sends a request to the test server, with the given session id and accepted content types.
+func requestHttpTest(t *testing.T, server *httptest.Server, method, sessionId, accept, body string) *http.Response {
+	request, err := http.NewRequest(method, server.URL, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+	if sessionId != "" {
+		request.Header.Set(SessionIdHeader, sessionId)
+	}
+	request.Header.Set("Accept", accept)
+
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+	return response
+}
+
+// initializeHttpTest initializes a session for a client with the given capabilities, returning its id.
+func initializeHttpTest(t *testing.T, server *httptest.Server, capabilities string) string {
+	response := requestHttpTest(t, server, http.MethodPost, "", "application/json, text/event-stream",
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":`+capabilities+`,"clientInfo":{"name":"client","version":"1.0"}}}`)
+	response.Body.Close()
+
+	sessionId := response.Header.Get(SessionIdHeader)
+	if response.StatusCode != http.StatusOK || sessionId == "" {
+		t.Fatalf("Expected a session but got status %d and id %q", response.StatusCode, sessionId)
+	}
+
+	response = requestHttpTest(t, server, http.MethodPost, sessionId, "application/json, text/event-stream", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	response.Body.Close()
+	return sessionId
+}
+
+// eventsHttpTest returns the events of an SSE response.
+func eventsHttpTest(body io.Reader) chan Event {
+	events := make(chan Event, 10)
+	go func() {
+		defer close(events)
+		var event Event
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+				event.Id = id
+			}
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				event.Message = data
+				events <- event
+			}
+		}
+	}()
+	return events
+}
+
+func TestHttpTransportSession(t *testing.T) {
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewTool("echo", "echo", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		return types.NewToolResult(nil), nil
+	}))
+
+	server := startHttpTest(t, mcpserver)
+	sessionId := initializeHttpTest(t, server, `{}`)
+	otherSessionId := initializeHttpTest(t, server, `{}`)
+
+	if sessionId == otherSessionId {
+		t.Errorf("Expected different session ids but got %s", sessionId)
+	}
+
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}`
+
+	table := []struct {
+		method         string
+		sessionId      string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{http.MethodPost, sessionId, call, http.StatusOK, `{"jsonrpc":"2.0","id":2,"result":{"content":null}}` + "\n"},
+		{http.MethodPost, otherSessionId, call, http.StatusOK, `{"jsonrpc":"2.0","id":2,"result":{"content":null}}` + "\n"},
+		{http.MethodPost, "", call, http.StatusBadRequest, "Bad Request: missing Mcp-Session-Id header\n"},
+		{http.MethodPost, "unknown", call, http.StatusNotFound, "Session Not Found\n"},
+		{http.MethodPost, sessionId, `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"p","progress":1}}`, http.StatusAccepted, ""},
+		{http.MethodPut, sessionId, call, http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+		{http.MethodDelete, sessionId, "", http.StatusNoContent, ""},
+		// the session is terminated, the other is not
+		{http.MethodPost, sessionId, call, http.StatusNotFound, "Session Not Found\n"},
+		{http.MethodPost, otherSessionId, call, http.StatusOK, `{"jsonrpc":"2.0","id":2,"result":{"content":null}}` + "\n"},
+	}
+
+	for _, test := range table {
+		response := requestHttpTest(t, server, test.method, test.sessionId, "application/json, text/event-stream", test.body)
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if response.StatusCode != test.expectedStatus {
+			t.Errorf("Expected %d but got %d", test.expectedStatus, response.StatusCode)
+		}
+
+		if string(body) != test.expectedBody {
+			t.Errorf("Expected %q but got %q", test.expectedBody, string(body))
+		}
+	}
+}
+
+func TestHttpTransportWithProtocolVersion(t *testing.T) {
+	server := startHttpTest(t, gomcp.New("test", "1.0"))
+	sessionId := initializeHttpTest(t, server, `{}`)
+
+	table := []struct {
+		version        string
+		expectedStatus int
+	}{
+		{"", http.StatusOK},
+		{"2025-06-18", http.StatusOK},
+		{"2024-11-05", http.StatusBadRequest},
+	}
+
+	for _, test := range table {
+		request, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+		request.Header.Set(SessionIdHeader, sessionId)
+		if test.version != "" {
+			request.Header.Set(ProtocolVersionHeader, test.version)
+		}
+
+		response, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatalf("Expected nil but got %v", err)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.expectedStatus {
+			t.Errorf("Expected %d but got %d", test.expectedStatus, response.StatusCode)
+		}
+	}
+}
+
+func TestHttpTransportWithFailedInitialize(t *testing.T) {
+	transport := NewHttpTransport(0)
+	gomcp.New("test", "1.0").WithTransport(transport)
+	server := httptest.NewServer(transport.Handler())
+	defer server.Close()
+
+	for range 3 {
+		response := requestHttpTest(t, server, http.MethodPost, "", "application/json, text/event-stream",
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"1999-01-01","clientInfo":{"name":"client","version":"1.0"}}}`)
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if !strings.Contains(string(body), `"error"`) {
+			t.Errorf("Expected an error response but got %s", body)
+		}
+		if sessionId := response.Header.Get(SessionIdHeader); sessionId != "" {
+			t.Errorf("Expected no session but got %s", sessionId)
+		}
+	}
+
+	if sessions := transport.sessionList(); len(sessions) != 0 {
+		t.Errorf("Expected no sessions but got %d", len(sessions))
+	}
+}
+
+func TestHttpTransportWithSessionTimeout(t *testing.T) {
+	transport := NewHttpTransport(0).WithSessionTimeout(50 * time.Millisecond)
+	gomcp.New("test", "1.0").WithTransport(transport)
+	server := httptest.NewServer(transport.Handler())
+	defer server.Close()
+
+	idleSessionId := initializeHttpTest(t, server, `{}`)
+	streamSessionId := initializeHttpTest(t, server, `{}`)
+
+	// the open GET stream keeps its session alive
+	stream := requestHttpTest(t, server, http.MethodGet, streamSessionId, "text/event-stream", "")
+	defer stream.Body.Close()
+
+	time.Sleep(200 * time.Millisecond)
+
+	table := []struct {
+		sessionId      string
+		expectedStatus int
+	}{
+		{idleSessionId, http.StatusNotFound},
+		{streamSessionId, http.StatusOK},
+	}
+
+	for _, test := range table {
+		response := requestHttpTest(t, server, http.MethodPost, test.sessionId, "application/json, text/event-stream", `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+		response.Body.Close()
+
+		if response.StatusCode != test.expectedStatus {
+			t.Errorf("Expected %d but got %d", test.expectedStatus, response.StatusCode)
+		}
+	}
+}
+
+func TestHttpTransportWithAllowedOrigins(t *testing.T) {
+	table := []struct {
+		origins        []string
+		origin         string
+		expectedStatus int
+	}{
+		{nil, "", http.StatusOK},
+		{nil, "http://localhost:3000", http.StatusOK},
+		{nil, "http://127.0.0.1:3000", http.StatusOK},
+		{nil, "http://[::1]", http.StatusOK},
+		{nil, "http://attacker.example", http.StatusForbidden},
+		{nil, "null", http.StatusForbidden},
+		{[]string{"https://app.example.com"}, "https://app.example.com", http.StatusOK},
+		{[]string{"https://app.example.com"}, "http://app.example.com", http.StatusForbidden},
+		{[]string{"https://app.example.com"}, "http://localhost:3000", http.StatusOK},
+		{[]string{"*"}, "http://attacker.example", http.StatusOK},
+	}
+
+	for _, test := range table {
+		transport := NewHttpTransport(0).WithAllowedOrigins(test.origins...)
+		gomcp.New("test", "1.0").WithTransport(transport)
+		server := httptest.NewServer(transport.Handler())
+
+		request, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","clientInfo":{"name":"client","version":"1.0"}}}`))
+		if test.origin != "" {
+			request.Header.Set("Origin", test.origin)
+		}
+
+		response, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatalf("Expected nil but got %v", err)
+		}
+		response.Body.Close()
+		server.Close()
+
+		if response.StatusCode != test.expectedStatus {
+			t.Errorf("Expected %d for origin %q but got %d", test.expectedStatus, test.origin, response.StatusCode)
+		}
+	}
+}
+
+func TestHttpTransportWithProgress(t *testing.T) {
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewContextTool("index", "index", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		gomcp.ProgressFromContext(ctx).Report(1, 1, "indexed")
+		return types.NewToolResult(nil), nil
+	}))
+
+	server := startHttpTest(t, mcpserver)
+	sessionId := initializeHttpTest(t, server, `{}`)
+
+	response := requestHttpTest(t, server, http.MethodPost, sessionId, "application/json, text/event-stream",
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"index","_meta":{"progressToken":"p"}}}`)
+	defer response.Body.Close()
+
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected %s but got %s", "text/event-stream", contentType)
+	}
+
+	// the progress and the response are sent on the stream of the request
+	expected := []string{
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"p","progress":1,"total":1,"message":"indexed"}}`,
+		`{"jsonrpc":"2.0","id":2,"result":{"content":null}}`,
+	}
+	events := eventsHttpTest(response.Body)
+	for _, e := range expected {
+		if actual := (<-events).Message; actual != e {
+			t.Errorf("Expected %s but got %s", e, actual)
+		}
+	}
+
+	for event := range events {
+		t.Errorf("Expected no more events but got %s", event.Message)
+	}
+}
+
+func TestHttpTransportWithStandaloneStream(t *testing.T) {
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewContextTool("summarize", "summarize", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		result, err := gomcp.CreateMessage(ctx, types.NewCreateMessageParams(nil, 10))
+		if err != nil {
+			return nil, err
+		}
+		return types.NewToolResult([]types.OperationContent{result.Content}), nil
+	}))
+
+	server := startHttpTest(t, mcpserver)
+	sessionId := initializeHttpTest(t, server, `{"sampling":{}}`)
+
+	response := requestHttpTest(t, server, http.MethodGet, sessionId, "application/json", "")
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotAcceptable {
+		t.Errorf("Expected %d but got %d", http.StatusNotAcceptable, response.StatusCode)
+	}
+
+	stream := requestHttpTest(t, server, http.MethodGet, sessionId, "text/event-stream", "")
+	defer stream.Body.Close()
+	events := eventsHttpTest(stream.Body)
+
+	// the client doesn't accept a stream for the request, so the sampling request goes on the standalone stream
+	responses := make(chan *http.Response)
+	go func() {
+		responses <- requestHttpTest(t, server, http.MethodPost, sessionId, "application/json", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"summarize"}}`)
+	}()
+
+	expected := `{"jsonrpc":"2.0","id":1,"method":"sampling/createMessage","params":{"messages":null,"maxTokens":10}}`
+	if actual := (<-events).Message; actual != expected {
+		t.Errorf("Expected %s but got %s", expected, actual)
+	}
+
+	response = requestHttpTest(t, server, http.MethodPost, sessionId, "application/json, text/event-stream",
+		`{"jsonrpc":"2.0","id":1,"result":{"role":"assistant","content":{"type":"text","text":"summary"},"model":"m"}}`)
+	response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected %d but got %d", http.StatusAccepted, response.StatusCode)
+	}
+
+	response = <-responses
+	body, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+
+	expected = `{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"summary"}]}}` + "\n"
+	if string(body) != expected {
+		t.Errorf("Expected %s but got %s", expected, string(body))
+	}
+
+	// the stream is closed when the session is terminated
+	requestHttpTest(t, server, http.MethodDelete, sessionId, "", "").Body.Close()
+	for event := range events {
+		t.Errorf("Expected no more events but got %s", event.Message)
+	}
+}
+
+func TestHttpTransportWithResumedStream(t *testing.T) {
+	release := make(chan struct{})
+
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewContextTool("index", "index", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		gomcp.ProgressFromContext(ctx).Report(1, 3, "started")
+		<-release
+		gomcp.ProgressFromContext(ctx).Report(3, 3, "indexed")
+		return types.NewToolResult(nil), nil
+	}))
+
+	server := startHttpTest(t, mcpserver)
+	sessionId := initializeHttpTest(t, server, `{}`)
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	request, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL,
+		strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"index","_meta":{"progressToken":"p"}}}`))
+	request.Header.Set(SessionIdHeader, sessionId)
+	request.Header.Set("Accept", "application/json, text/event-stream")
+
+	response, err := server.Client().Do(request)
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+
+	first := <-eventsHttpTest(response.Body)
+	expected := `{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"p","progress":1,"total":3,"message":"started"}}`
+	if first.Message != expected {
+		t.Errorf("Expected %s but got %s", expected, first.Message)
+	}
+
+	// the connection is lost in the middle of the stream
+	disconnect()
+	response.Body.Close()
+
+	resume := func(lastEventId string) *http.Response {
+		request, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		request.Header.Set(SessionIdHeader, sessionId)
+		request.Header.Set("Accept", "text/event-stream")
+		request.Header.Set(LastEventIdHeader, lastEventId)
+
+		response, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatalf("Expected nil but got %v", err)
+		}
+		return response
+	}
+
+	resumed := resume(first.Id)
+	close(release)
+
+	// the request is not cancelled, and the following events are sent on the resumed stream
+	expectedEvents := []string{
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"p","progress":3,"total":3,"message":"indexed"}}`,
+		`{"jsonrpc":"2.0","id":2,"result":{"content":null}}`,
+	}
+	var last Event
+	events := eventsHttpTest(resumed.Body)
+	for _, e := range expectedEvents {
+		if last = <-events; last.Message != e {
+			t.Errorf("Expected %s but got %s", e, last.Message)
+		}
+	}
+
+	for event := range events {
+		t.Errorf("Expected no more events but got %s", event.Message)
+	}
+	resumed.Body.Close()
+
+	// the ended stream can be replayed
+	table := []struct {
+		lastEventId    string
+		expectedStatus int
+		expectedEvents int
+	}{
+		{first.Id, http.StatusOK, 2},
+		{last.Id, http.StatusOK, 0},
+		{"unknown", http.StatusNotFound, 0},
+	}
+
+	for _, test := range table {
+		replayed := resume(test.lastEventId)
+
+		count := 0
+		for range eventsHttpTest(replayed.Body) {
+			count++
+		}
+		replayed.Body.Close()
+
+		if replayed.StatusCode != test.expectedStatus {
+			t.Errorf("Expected %d but got %d", test.expectedStatus, replayed.StatusCode)
+		}
+
+		if count != test.expectedEvents {
+			t.Errorf("Expected %d but got %d", test.expectedEvents, count)
+		}
+	}
+}
+
+func TestHttpTransportHandler(t *testing.T) {
+	transport := NewHttpTransport(0)
+	gomcp.New("test", "1.0").WithTransport(transport)
+
+	// the endpoint is mounted on a router of the application, behind a middleware
+	mux := http.NewServeMux()
+	mux.Handle("/api/mcp", transport.Handler())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	table := []struct {
+		path           string
+		authorization  string
+		expectedStatus int
+	}{
+		{"/api/mcp", "Bearer token", http.StatusOK},
+		{"/api/mcp", "", http.StatusUnauthorized},
+		{"/mcp", "Bearer token", http.StatusNotFound},
+	}
+
+	for _, test := range table {
+		request, _ := http.NewRequest(http.MethodPost, server.URL+test.path,
+			strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","clientInfo":{"name":"client","version":"1.0"}}}`))
+		request.Header.Set("Authorization", test.authorization)
+
+		response, err := server.Client().Do(request)
+		if err != nil {
+			t.Fatalf("Expected nil but got %v", err)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != test.expectedStatus {
+			t.Errorf("Expected %d but got %d", test.expectedStatus, response.StatusCode)
+		}
+	}
+}
+
+func TestHttpTransportStart(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	server := &http.Server{Addr: addr}
+	transport := NewHttpTransport(0).WithServer(server).WithPath("/custom")
+	gomcp.New("test", "1.0").WithTransport(transport)
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- transport.Start(context.Background())
+	}()
+
+	// the endpoint is served on the custom path once the server is listening
+	var response *http.Response
+	for range 100 {
+		response, err = http.Post("http://"+addr+"/custom", "application/json",
+			strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","clientInfo":{"name":"client","version":"1.0"}}}`))
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Expected nil but got %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Expected %d but got %d", http.StatusOK, response.StatusCode)
+	}
+
+	// the server is shut down without stopping the process
+	if err := transport.Stop(context.Background()); err != nil {
+		t.Errorf("Expected nil but got %v", err)
+	}
+	if err := <-stopped; err != nil {
+		t.Errorf("Expected nil but got %v", err)
+	}
+
+	// the errors are returned
+	transport = NewHttpTransport(0).WithServer(&http.Server{Addr: "127.0.0.1:-1"})
+	var opErr *net.OpError
+	if err := transport.Start(context.Background()); !errors.As(err, &opErr) {
+		t.Errorf("Expected a *net.OpError but got %#v", err)
+	}
+}
+
+func TestHttpTransportStop(t *testing.T) {
+	table := []struct {
+		release           bool
+		expectedErr       error
+		expectedCancelled bool
+	}{
+		{
+			// the request in progress completes
+			true,
+			nil,
+			false,
+		},
+		{
+			// the request in progress is cancelled after the deadline
+			false,
+			context.DeadlineExceeded,
+			true,
+		},
+	}
+
+	for _, test := range table {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		cancelled := make(chan bool, 1)
+
+		mcpserver := gomcp.New("test", "1.0")
+		mcpserver.AddTool(types.NewContextTool("slow", "slow", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+			close(started)
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			cancelled <- ctx.Err() != nil
+			return types.NewToolResult(nil), nil
+		}))
+
+		transport := NewHttpTransport(0)
+		mcpserver.WithTransport(transport)
+		server := httptest.NewServer(transport.Handler())
+		defer server.Close()
+		sessionId := initializeHttpTest(t, server, `{}`)
+
+		stream := requestHttpTest(t, server, http.MethodGet, sessionId, "text/event-stream", "")
+		responses := make(chan *http.Response)
+		go func() {
+			responses <- requestHttpTest(t, server, http.MethodPost, sessionId, "application/json", `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`)
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		stopped := make(chan error)
+		go func() {
+			stopped <- transport.Stop(ctx)
+		}()
+
+		// the standalone stream is closed at once, and the new requests are refused
+		io.ReadAll(stream.Body)
+		stream.Body.Close()
+
+		refused := requestHttpTest(t, server, http.MethodPost, sessionId, "application/json", `{"jsonrpc":"2.0","id":3,"method":"tools/list"}`)
+		refused.Body.Close()
+		if refused.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected %d but got %d", http.StatusServiceUnavailable, refused.StatusCode)
+		}
+
+		if test.release {
+			close(release)
+		}
+
+		if err := <-stopped; !errors.Is(err, test.expectedErr) {
+			t.Errorf("Expected %#v but got %#v", test.expectedErr, err)
+		}
+		cancel()
+
+		response := <-responses
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		expected := `{"jsonrpc":"2.0","id":2,"result":{"content":null}}` + "\n"
+		if string(body) != expected {
+			t.Errorf("Expected %q but got %q", expected, string(body))
+		}
+
+		if actual := <-cancelled; actual != test.expectedCancelled {
+			t.Errorf("Expected %v but got %v", test.expectedCancelled, actual)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+var errStreamClosed = errors.New("stream closed")
+
+// sseStream is a stream of Server-Sent Events of a session. Its events are stored so that it outlives
+// the connection of the client: once detached, the events are only stored, until the client resumes
+// the stream with the Last-Event-ID header on a new connection.
+type sseStream struct {
+	id      string
+	session string
+	store   EventStore
+
+	mu       sync.Mutex
+	w        http.ResponseWriter // nil when detached
+	detached chan struct{}       // closed when w is detached
+	done     chan struct{}       // closed when the stream ends
+}
+
+func newSSEStream(id, session string, store EventStore) *sseStream {
+	return &sseStream{id: id, session: session, store: store, done: make(chan struct{})}
+}
+
+// startSSE starts an SSE response on w.
+func startSSE(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes event on w and flushes it.
+func writeEvent(w http.ResponseWriter, event Event) error {
+	if _, err := fmt.Fprintf(w, "id: %s\nevent: message\ndata: %s\n\n", event.Id, event.Message); err != nil {
+		return err
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// send stores message as an event of the stream and writes it to the client, if attached.
+func (s *sseStream) send(message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return errStreamClosed
+	default:
+	}
+
+	id, err := s.store.Store(s.session, s.id, message)
+	if err != nil {
+		return err
+	}
+
+	if s.w != nil {
+		if err := writeEvent(s.w, Event{id, message}); err != nil {
+			// the client is gone, it can resume the stream
+			s.detachLocked()
+		}
+	}
+	return nil
+}
+
+// attach sets w as the connection of the stream, detaching the previous one.
+// It returns a channel closed when w is detached.
+func (s *sseStream) attach(w http.ResponseWriter) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attachLocked(w)
+}
+
+// resume writes to w the events sent after lastEventId, then attaches w to the stream.
+// It returns a channel closed when w is detached.
+func (s *sseStream) resume(w http.ResponseWriter, lastEventId string) (<-chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the events sent while replaying would be lost, so the stream is locked
+	_, events, err := s.store.Replay(s.session, lastEventId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if err := writeEvent(w, event); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.attachLocked(w), nil
+}
+
+func (s *sseStream) attachLocked(w http.ResponseWriter) <-chan struct{} {
+	s.detachLocked()
+	s.w = w
+	s.detached = make(chan struct{})
+	return s.detached
+}
+
+// detach detaches the connection of the stream, unless it was already replaced.
+func (s *sseStream) detach(detached <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.detached == detached {
+		s.detachLocked()
+	}
+}
+
+func (s *sseStream) detachLocked() {
+	if s.w != nil {
+		s.w = nil
+		close(s.detached)
+	}
+}
+
+// close ends the stream, the following messages are not sent.
+func (s *sseStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
@@ -0,0 +1,361 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/mcpunzo/gomcp"
+	"github.com/mcpunzo/gomcp/types"
+)
+
+// DefaultStdioWorkers is the default maximum number of requests handled concurrently by the StdioTransport.
+const DefaultStdioWorkers = 16
+
+type StdioTransport struct {
+	mgp     *gomcp.MCPServer
+	in      io.Reader
+	out     io.Writer
+	framing Framing
+	maxSize int
+	workers int
+
+	mu     sync.Mutex
+	stop   chan struct{}      // closed by Stop
+	done   chan struct{}      // closed when Start returns
+	cancel context.CancelFunc // cancels the requests in progress
+}
+
+func NewStdIOTransport() *StdioTransport {
+	return NewStdIOTransportWithStreams(os.Stdin, os.Stdout)
+}
+
+// NewStdIOTransportWithStreams creates a StdioTransport reading from in and writing to out instead of
+// stdin and stdout, e.g. the pipes of a subprocess. A nil stream defaults to stdin or stdout.
+func NewStdIOTransportWithStreams(in io.Reader, out io.Writer) *StdioTransport {
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	return &StdioTransport{in: in, out: out, maxSize: DefaultMaxMessageSize, workers: DefaultStdioWorkers}
+}
+
+// WithFraming sets how the messages are delimited on the streams, FramingNewline by default.
+func (s *StdioTransport) WithFraming(framing Framing) *StdioTransport {
+	s.framing = framing
+	return s
+}
+
+// WithMaxMessageSize sets the maximum size in bytes of the messages read, without their newline or
+// Content-Length header, DefaultMaxMessageSize by default. A larger message stops the transport with
+// ErrMessageTooLarge. A size lower than 1 means no limit.
+func (s *StdioTransport) WithMaxMessageSize(size int) *StdioTransport {
+	s.maxSize = size
+	return s
+}
+
+// WithWorkers sets the maximum number of requests handled concurrently, DefaultStdioWorkers by default.
+// A limit lower than 1 means no limit.
+func (s *StdioTransport) WithWorkers(workers int) *StdioTransport {
+	s.workers = workers
+	return s
+}
+
+// SetMCPServer sets the MCPServer for the StdioTransport.
+func (s *StdioTransport) SetMCPServer(mcpserver *gomcp.MCPServer) {
+	s.mgp = mcpserver
+}
+
+// Start starts the StdioTransport to read from its input and write to its output, stdin and stdout by default.
+// Requests are queued in arrival order and handled concurrently by a pool of the configured number of
+// workers, and their responses are written as they complete, so a slow request doesn't block the others; notifications and responses
+// are handled in order as soon as they are read, so that a request in progress can be cancelled or
+// receive the responses to the requests it sent to the client. The initialize request is handled in order
+// as well, before reading the following messages.
+// When the input ends, the client sends the exit notification or Stop is called, Start waits for the
+// requests in progress to complete before returning. When ctx is cancelled, the requests in progress
+// are cancelled and ctx.Err() is returned. It returns the error that stopped the reading of the input, if any.
+func (s *StdioTransport) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.mu.Lock()
+	if s.stop == nil {
+		s.stop = make(chan struct{})
+	}
+	stop := s.stop
+	s.done = make(chan struct{})
+	done := s.done
+	s.cancel = cancel
+	s.mu.Unlock()
+
+	log.Print("Server started")
+	writer := newMessageWriter(s.out, s.framing)
+
+	// notifications sent by the server share the output with the responses
+	s.mgp.Session().SetSender(writer.WriteMessage)
+
+	handle := func(line string) {
+		response, _ := s.mgp.HandleContext(ctx, s.mgp.Session(), line)
+		writer.WriteMessage(response)
+	}
+
+	// the requests are queued in arrival order and handled by a fixed pool of workers, so the reader
+	// goes on reading notifications when all workers are busy
+	queue := newRequestQueue()
+	var wg sync.WaitGroup
+	for range s.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				line, ok := queue.pop()
+				if !ok {
+					return
+				}
+				if ctx.Err() != nil {
+					// cancelled, the queued requests are dropped
+					continue
+				}
+				handle(line)
+			}
+		}()
+	}
+
+	defer func() {
+		queue.close()
+		wg.Wait()
+		log.Print("Server stopped")
+		close(done)
+	}()
+
+	lines, errs := s.readMessages(ctx)
+
+	// drained is set once stopping, and closed when the requests in progress are complete
+	var drained chan struct{}
+
+	for {
+		select {
+		case line := <-lines:
+			method, id, request := requestMethod(line)
+			if request && drained != nil {
+				log.Print("Server stopping, request dropped")
+			} else if !request || method == gomcp.Initialize {
+				if method == gomcp.Cancelled {
+					// a request waiting for a worker is not in progress yet, it is removed from the queue
+					if requestId, ok := cancelledRequestId(line); ok && queue.remove(requestId) {
+						log.Printf("Request %s cancelled while queued", requestId)
+					}
+				}
+
+				// initialize is handled in order too, so that the initialized notification following it
+				// finds the session initializing even when the client doesn't wait for the response
+				response, _ := s.mgp.HandleContext(ctx, s.mgp.Session(), line)
+				writer.WriteMessage(response)
+			} else if s.workers > 0 {
+				queue.push(id, line)
+			} else {
+				// no limit, each request has its own goroutine
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					handle(line)
+				}()
+			}
+
+		case err := <-errs:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+
+		case <-stop:
+			// notifications and responses are still handled while the requests in progress complete
+			stop = nil
+			drained = make(chan struct{})
+			queue.close()
+			go func() {
+				wg.Wait()
+				close(drained)
+			}()
+
+		case <-drained:
+			return nil
+
+		case <-ctx.Done():
+			if drained != nil {
+				// cancelled by Stop
+				return nil
+			}
+			return ctx.Err()
+		}
+
+		// the client sent the exit notification
+		select {
+		case <-s.mgp.Session().Done():
+			return nil
+		default:
+		}
+	}
+}
+
+// Stop stops reading requests and waits for the requests in progress to complete. When ctx is done
+// before they complete, they are cancelled and ctx.Err() is returned.
+func (s *StdioTransport) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stop == nil {
+		s.stop = make(chan struct{})
+	}
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	done, cancel := s.done, s.cancel
+	s.mu.Unlock()
+
+	if done == nil {
+		// not started
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		cancel()
+		return ctx.Err()
+	}
+}
+
+// requestQueue is a FIFO queue of the requests waiting for a worker. It is unbounded, so that
+// queueing a request never blocks the reader.
+type requestQueue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	requests []queuedRequest
+	closed   bool
+}
+
+// queuedRequest is a request waiting in the requestQueue, with its id to cancel it.
+type queuedRequest struct {
+	id   string
+	line string
+}
+
+func newRequestQueue() *requestQueue {
+	q := &requestQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push appends line, the request with the given id, to the queue.
+func (q *requestQueue) push(id, line string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.requests = append(q.requests, queuedRequest{id: id, line: line})
+	q.cond.Signal()
+}
+
+// remove removes the first request with the given id from the queue, reporting whether it was found.
+func (q *requestQueue) remove(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := slices.IndexFunc(q.requests, func(request queuedRequest) bool { return request.id == id })
+	if i < 0 {
+		return false
+	}
+
+	q.requests = slices.Delete(q.requests, i, i+1)
+	return true
+}
+
+// pop waits for the first request of the queue and removes it. It returns false once the queue
+// is closed and empty.
+func (q *requestQueue) pop() (string, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.requests) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.requests) == 0 {
+		return "", false
+	}
+
+	request := q.requests[0]
+	q.requests[0] = queuedRequest{}
+	q.requests = q.requests[1:]
+	return request.line, true
+}
+
+// close wakes up the workers waiting for requests; the queued requests are still popped.
+func (q *requestQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// readMessages reads the messages of the input until ctx is done, sending them and then the error
+// that stopped the reading, io.EOF at the end of the input.
+func (s *StdioTransport) readMessages(ctx context.Context) (<-chan string, <-chan error) {
+	messages := make(chan string)
+	errs := make(chan error, 1)
+
+	go func() {
+		reader := newMessageReader(s.in, s.framing, s.maxSize)
+		for {
+			message, err := reader.ReadMessage()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case messages <- message:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return messages, errs
+}
+
+// requestMethod returns the method and the id of line and reports whether it is a JSON-RPC request, as
+// opposed to a notification or a response of the client to a request of the server. Batches and invalid
+// messages are handled as requests without method nor id.
+func requestMethod(line string) (string, string, bool) {
+	var message struct {
+		Id     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if err := json.Unmarshal([]byte(line), &message); err != nil {
+		return "", "", true
+	}
+
+	return message.Method, string(message.Id), message.Id != nil && message.Method != ""
+}
+
+// cancelledRequestId returns the id of the request cancelled by line, a notifications/cancelled,
+// in the form used by the session to track the requests in progress.
+func cancelledRequestId(line string) (string, bool) {
+	var message struct {
+		Params types.CancelledParams `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(line), &message); err != nil || message.Params.RequestId.IsZero() {
+		return "", false
+	}
+
+	return message.Params.RequestId.String(), true
+}
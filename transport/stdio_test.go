@@ -0,0 +1,526 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mcpunzo/gomcp"
+	"github.com/mcpunzo/gomcp/types"
+)
+
+func TestStdioTransportWithCancelledRequest(t *testing.T) {
+	started := make(chan struct{})
+	reasons := make(chan string, 1)
+
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewContextTool("wait", "wait", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		close(started)
+		<-ctx.Done()
+
+		reason, _ := gomcp.CancellationReasonFromContext(ctx)
+		reasons <- reason
+		return types.NewToolResult(nil), nil
+	}))
+
+	clientWriter, responses := startStdioTest(t, mcpserver, DefaultStdioWorkers)
+
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"wait"}}`+"\n")
+
+	<-started
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2,"reason":"user requested"}}`+"\n")
+
+	if reason := <-reasons; reason != "user requested" {
+		t.Errorf("Expected %s but got %s", "user requested", reason)
+	}
+
+	clientWriter.Close()
+
+	for responses.Scan() {
+		t.Errorf("Expected no response to the cancelled request but got %s", responses.Text())
+	}
+}
+
+func TestStdioTransportWithProgress(t *testing.T) {
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewContextTool("index", "index", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		gomcp.ProgressFromContext(ctx).Report(1, 1, "indexed")
+		return types.NewToolResult(nil), nil
+	}))
+
+	clientWriter, responses := startStdioTest(t, mcpserver, DefaultStdioWorkers)
+
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"index","_meta":{"progressToken":"p"}}}`+"\n")
+
+	expected := []string{
+		`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"p","progress":1,"total":1,"message":"indexed"}}`,
+		`{"jsonrpc":"2.0","id":2,"result":{"content":null}}`,
+	}
+	for _, e := range expected {
+		if !responses.Scan() || responses.Text() != e {
+			t.Errorf("Expected %s but got %s", e, responses.Text())
+		}
+	}
+
+	clientWriter.Close()
+	for responses.Scan() {
+		t.Errorf("Expected no more responses but got %s", responses.Text())
+	}
+}
+
+// startStdioTest starts a StdioTransport with the given number of workers serving mcpserver,
+// and initializes the session. It returns the input of the transport and a scanner of its output.
+func startStdioTest(t *testing.T, mcpserver *gomcp.MCPServer, workers int) (*io.PipeWriter, *bufio.Scanner) {
+	in, clientWriter := io.Pipe()
+	clientReader, out := io.Pipe()
+	transport := NewStdIOTransportWithStreams(in, out)
+	mcpserver.WithTransport(transport.WithWorkers(workers))
+
+	go func() {
+		transport.Start(context.Background())
+		out.Close()
+	}()
+
+	responses := bufio.NewScanner(clientReader)
+	initializeStdioTest(t, clientWriter, responses)
+
+	return clientWriter, responses
+}
+
+// initializeStdioTest initializes the session through the input and the output of a StdioTransport.
+func initializeStdioTest(t *testing.T, clientWriter io.Writer, responses *bufio.Scanner) {
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","clientInfo":{"name":"client","version":"1.0"}}}`+"\n")
+	if !responses.Scan() {
+		t.Fatalf("Expected the initialize response")
+	}
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n")
+}
+
+func TestStdioTransportWithPipelinedInitialize(t *testing.T) {
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewTool("fast", "fast", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		return types.NewToolResult(nil), nil
+	}))
+
+	// the client sends the following messages without waiting for the initialize response
+	in := strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","clientInfo":{"name":"client","version":"1.0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"fast"}}`,
+	}, "\n") + "\n")
+	out := &strings.Builder{}
+	mcpserver.WithTransport(NewStdIOTransportWithStreams(in, out))
+
+	if err := mcpserver.Run(context.Background()); err != nil {
+		t.Fatalf("Expected no error but got %#v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	expected := `{"jsonrpc":"2.0","id":2,"result":{"content":null}}`
+	if len(lines) != 2 || lines[1] != expected {
+		t.Errorf("Expected %s but got %s", expected, out.String())
+	}
+}
+
+func TestStdioTransportWithConcurrentRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewTool("slow", "slow", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		close(started)
+		<-release
+		return types.NewToolResult(nil), nil
+	}))
+	mcpserver.AddTool(types.NewTool("fast", "fast", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		return types.NewToolResult(nil), nil
+	}))
+
+	clientWriter, responses := startStdioTest(t, mcpserver, 2)
+
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`+"\n")
+	<-started
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"fast"}}`+"\n")
+
+	// the fast request is answered while the slow one is in progress
+	expected := `{"jsonrpc":"2.0","id":3,"result":{"content":null}}`
+	if !responses.Scan() || responses.Text() != expected {
+		t.Errorf("Expected %s but got %s", expected, responses.Text())
+	}
+
+	// the slow request is drained when the input ends
+	clientWriter.Close()
+	close(release)
+
+	expected = `{"jsonrpc":"2.0","id":2,"result":{"content":null}}`
+	if !responses.Scan() || responses.Text() != expected {
+		t.Errorf("Expected %s but got %s", expected, responses.Text())
+	}
+
+	for responses.Scan() {
+		t.Errorf("Expected no more responses but got %s", responses.Text())
+	}
+}
+
+func TestStdioTransportWithWorkerLimit(t *testing.T) {
+	started := make(chan struct{})
+
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewContextTool("slow", "slow", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return types.NewToolResult(nil), nil
+	}))
+	mcpserver.AddTool(types.NewTool("fast", "fast", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		return types.NewToolResult(nil), nil
+	}))
+
+	clientWriter, responses := startStdioTest(t, mcpserver, 1)
+
+	// the only worker is busy with the slow request, so the fast one waits for it,
+	// while the cancellation is read and handled
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`+"\n")
+	<-started
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"fast"}}`+"\n")
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2}}`+"\n")
+
+	expected := `{"jsonrpc":"2.0","id":3,"result":{"content":null}}`
+	if !responses.Scan() || responses.Text() != expected {
+		t.Errorf("Expected %s but got %s", expected, responses.Text())
+	}
+
+	clientWriter.Close()
+	for responses.Scan() {
+		t.Errorf("Expected no more responses but got %s", responses.Text())
+	}
+}
+
+func TestStdioTransportWithSampling(t *testing.T) {
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewContextTool("summarize", "summarize", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		result, err := gomcp.CreateMessage(ctx, types.NewCreateMessageParams(nil, 10))
+		if err != nil {
+			return nil, err
+		}
+		return types.NewToolResult([]types.OperationContent{result.Content}), nil
+	}))
+
+	in, clientWriter := io.Pipe()
+	clientReader, out := io.Pipe()
+	transport := NewStdIOTransportWithStreams(in, out)
+	mcpserver.WithTransport(transport.WithWorkers(1))
+
+	go func() {
+		transport.Start(context.Background())
+		out.Close()
+	}()
+
+	responses := bufio.NewScanner(clientReader)
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{"sampling":{}},"clientInfo":{"name":"client","version":"1.0"}}}`+"\n")
+	responses.Scan()
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","method":"notifications/initialized"}`+"\n")
+
+	// the response of the client is read even if the only worker is waiting for it
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"summarize"}}`+"\n")
+
+	expected := `{"jsonrpc":"2.0","id":1,"method":"sampling/createMessage","params":{"messages":null,"maxTokens":10}}`
+	if !responses.Scan() || responses.Text() != expected {
+		t.Errorf("Expected %s but got %s", expected, responses.Text())
+	}
+
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":1,"result":{"role":"assistant","content":{"type":"text","text":"summary"},"model":"m"}}`+"\n")
+
+	expected = `{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"summary"}]}}`
+	if !responses.Scan() || responses.Text() != expected {
+		t.Errorf("Expected %s but got %s", expected, responses.Text())
+	}
+
+	clientWriter.Close()
+	for responses.Scan() {
+		t.Errorf("Expected no more responses but got %s", responses.Text())
+	}
+}
+
+func TestStdioTransportStop(t *testing.T) {
+	table := []struct {
+		release           bool
+		expectedErr       error
+		expectedCancelled bool
+	}{
+		{
+			// the request in progress completes
+			true,
+			nil,
+			false,
+		},
+		{
+			// the request in progress is cancelled after the deadline
+			false,
+			context.DeadlineExceeded,
+			true,
+		},
+	}
+
+	for _, test := range table {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		cancelled := make(chan bool, 1)
+
+		mcpserver := gomcp.New("test", "1.0")
+		mcpserver.AddTool(types.NewContextTool("slow", "slow", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+			close(started)
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			cancelled <- ctx.Err() != nil
+			return types.NewToolResult(nil), nil
+		}))
+
+		in, clientWriter := io.Pipe()
+		clientReader, out := io.Pipe()
+		transport := NewStdIOTransportWithStreams(in, out)
+		mcpserver.WithTransport(transport)
+
+		finished := make(chan error)
+		go func() {
+			finished <- transport.Start(context.Background())
+			out.Close()
+		}()
+
+		responses := bufio.NewScanner(clientReader)
+		initializeStdioTest(t, clientWriter, responses)
+		io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`+"\n")
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		stopped := make(chan error)
+		go func() {
+			stopped <- transport.Stop(ctx)
+		}()
+
+		if test.release {
+			close(release)
+		}
+
+		expected := `{"jsonrpc":"2.0","id":2,"result":{"content":null}}`
+		if !responses.Scan() || responses.Text() != expected {
+			t.Errorf("Expected %s but got %s", expected, responses.Text())
+		}
+
+		if err := <-stopped; !errors.Is(err, test.expectedErr) {
+			t.Errorf("Expected %#v but got %#v", test.expectedErr, err)
+		}
+		cancel()
+
+		if err := <-finished; err != nil {
+			t.Errorf("Expected nil but got %v", err)
+		}
+
+		if actual := <-cancelled; actual != test.expectedCancelled {
+			t.Errorf("Expected %v but got %v", test.expectedCancelled, actual)
+		}
+
+		for responses.Scan() {
+			t.Errorf("Expected no more responses but got %s", responses.Text())
+		}
+		clientWriter.Close()
+	}
+}
+
+func TestStdioTransportWithCancelledContext(t *testing.T) {
+	started := make(chan struct{})
+
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewContextTool("slow", "slow", nil, func(ctx context.Context, _ map[string]any) (*types.ToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		return types.NewToolResult(nil), nil
+	}))
+
+	in, clientWriter := io.Pipe()
+	defer clientWriter.Close()
+	clientReader, out := io.Pipe()
+	transport := NewStdIOTransportWithStreams(in, out)
+	mcpserver.WithTransport(transport)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan error)
+	go func() {
+		finished <- mcpserver.Run(ctx)
+		out.Close()
+	}()
+
+	initializeStdioTest(t, clientWriter, bufio.NewScanner(clientReader))
+	go io.Copy(io.Discard, clientReader)
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`+"\n")
+	<-started
+
+	// the request in progress is cancelled with the server
+	cancel()
+	if err := <-finished; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %#v but got %#v", context.Canceled, err)
+	}
+}
+
+func TestStdioTransportWithFraming(t *testing.T) {
+	// a message larger than the default buffer of a bufio.Scanner
+	text := strings.Repeat("a", 1<<20)
+	messages := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","clientInfo":{"name":"client","version":"1.0"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"length","arguments":{"text":"` + text + `"}}}`,
+	}
+
+	for _, framing := range []Framing{FramingNewline, FramingContentLength} {
+		mcpserver := gomcp.New("test", "1.0")
+		mcpserver.AddTool(types.NewTool("length", "length", nil, func(args map[string]any) (*types.ToolResult, error) {
+			length := strconv.Itoa(len(args["text"].(string)))
+			return types.NewToolResult([]types.OperationContent{*types.NewOperationContent("text", length, "", nil)}), nil
+		}))
+
+		in, clientWriter := io.Pipe()
+		clientReader, out := io.Pipe()
+		mcpserver.WithTransport(NewStdIOTransportWithStreams(in, out).WithFraming(framing))
+
+		finished := make(chan error)
+		go func() {
+			finished <- mcpserver.Run(context.Background())
+			out.Close()
+		}()
+
+		writer := newMessageWriter(clientWriter, framing)
+		reader := newMessageReader(clientReader, framing, DefaultMaxMessageSize)
+		for i, message := range messages {
+			writer.WriteMessage(message)
+			if i == 1 {
+				continue
+			}
+
+			response, err := reader.ReadMessage()
+			if err != nil {
+				t.Fatalf("Expected a response but got %#v", err)
+			}
+			if i == 2 && !strings.Contains(response, `"text":"1048576"`) {
+				t.Errorf("Expected the length of the text but got %s", response)
+			}
+		}
+
+		// the end of the input stops the transport
+		clientWriter.Close()
+		if err := <-finished; err != nil {
+			t.Errorf("Expected no error but got %#v", err)
+		}
+		if response, err := reader.ReadMessage(); err != io.EOF {
+			t.Errorf("Expected no more responses but got %s", response)
+		}
+	}
+}
+
+func TestStdioTransportWithQueuedRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewTool("slow", "slow", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		close(started)
+		<-release
+		return types.NewToolResult(nil), nil
+	}))
+	mcpserver.AddTool(types.NewTool("fast", "fast", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		return types.NewToolResult(nil), nil
+	}))
+
+	clientWriter, responses := startStdioTest(t, mcpserver, 1)
+
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`+"\n")
+	<-started
+
+	// the requests wait for the only worker in arrival order, without a goroutine each
+	goroutines := runtime.NumGoroutine()
+	for id := 3; id < 1003; id++ {
+		io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":`+strconv.Itoa(id)+`,"method":"tools/call","params":{"name":"fast"}}`+"\n")
+	}
+	if n := runtime.NumGoroutine(); n > goroutines+10 {
+		t.Errorf("Expected at most %d goroutines but got %d", goroutines+10, n)
+	}
+	close(release)
+
+	for id := 2; id < 1003; id++ {
+		expected := `{"jsonrpc":"2.0","id":` + strconv.Itoa(id) + `,"result":{"content":null}}`
+		if !responses.Scan() || responses.Text() != expected {
+			t.Errorf("Expected %s but got %s", expected, responses.Text())
+		}
+	}
+
+	clientWriter.Close()
+	for responses.Scan() {
+		t.Errorf("Expected no more responses but got %s", responses.Text())
+	}
+}
+
+func TestStdioTransportWithMaxMessageSize(t *testing.T) {
+	var tests = []struct {
+		framing Framing
+		input   string
+	}{
+		// a line longer than the buffer of the reader, with no end
+		{FramingNewline, strings.Repeat("a", 1<<16)},
+		{FramingContentLength, "Content-Length: 9223372036854775807\r\n\r\n{}"},
+	}
+
+	for _, test := range tests {
+		mcpserver := gomcp.New("test", "1.0")
+		transport := NewStdIOTransportWithStreams(strings.NewReader(test.input), io.Discard).WithFraming(test.framing).WithMaxMessageSize(1024)
+		mcpserver.WithTransport(transport)
+
+		if err := mcpserver.Run(context.Background()); !errors.Is(err, ErrMessageTooLarge) {
+			t.Errorf("Expected %#v but got %#v", ErrMessageTooLarge, err)
+		}
+	}
+}
+
+func TestStdioTransportWithCancelledQueuedRequest(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mcpserver := gomcp.New("test", "1.0")
+	mcpserver.AddTool(types.NewTool("slow", "slow", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		close(started)
+		<-release
+		return types.NewToolResult(nil), nil
+	}))
+	mcpserver.AddTool(types.NewTool("fast", "fast", nil, func(_ map[string]any) (*types.ToolResult, error) {
+		return types.NewToolResult(nil), nil
+	}))
+	cancelled := make(chan struct{})
+	mcpserver.OnNotification(gomcp.Cancelled, func(_ any) {
+		close(cancelled)
+	})
+
+	clientWriter, responses := startStdioTest(t, mcpserver, 1)
+
+	// the fast request waits for the only worker, busy with the slow one, when it is cancelled
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`+"\n")
+	<-started
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"fast"}}`+"\n")
+	io.WriteString(clientWriter, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":3}}`+"\n")
+	<-cancelled
+	close(release)
+
+	expected := `{"jsonrpc":"2.0","id":2,"result":{"content":null}}`
+	if !responses.Scan() || responses.Text() != expected {
+		t.Errorf("Expected %s but got %s", expected, responses.Text())
+	}
+
+	clientWriter.Close()
+	for responses.Scan() {
+		t.Errorf("Expected no response to the cancelled request but got %s", responses.Text())
+	}
+}
@@ -0,0 +1,22 @@
+package transport
+
+import (
+	"context"
+	"sync"
+)
+
+// waitContext waits for wg until ctx is done, returning ctx.Err() then.
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
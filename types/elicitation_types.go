@@ -0,0 +1,37 @@
+package types
+
+// ElicitAction is the action taken by the user on an elicitation request.
+type ElicitAction string
+
+const (
+	// ElicitActionAccept means that the user submitted the requested content.
+	ElicitActionAccept ElicitAction = "accept"
+	// ElicitActionDecline means that the user explicitly declined the request.
+	ElicitActionDecline ElicitAction = "decline"
+	// ElicitActionCancel means that the user dismissed the request without choosing.
+	ElicitActionCancel ElicitAction = "cancel"
+)
+
+// ElicitParams represents the parameters of the elicitation/create request, asking the user
+// for input matching the requested schema, a flat object with properties of primitive types.
+type ElicitParams struct {
+	Message         string         `json:"message"`
+	RequestedSchema map[string]any `json:"requestedSchema"`
+}
+
+// ElicitResult represents the answer of the user to an elicitation request.
+// Content is only set when the action is accept.
+type ElicitResult struct {
+	Action  ElicitAction   `json:"action"`
+	Content map[string]any `json:"content,omitempty"`
+}
+
+// NewElicitParams creates a new ElicitParams with the given message and requested schema.
+func NewElicitParams(message string, requestedSchema map[string]any) *ElicitParams {
+	return &ElicitParams{Message: message, RequestedSchema: requestedSchema}
+}
+
+// NewElicitResult creates a new ElicitResult with the given action and content.
+func NewElicitResult(action ElicitAction, content map[string]any) *ElicitResult {
+	return &ElicitResult{Action: action, Content: content}
+}
@@ -13,31 +13,91 @@ type ServerInfo struct {
 }
 
 // Capabilities represents the server's capabilities.
+// A nil capability means that the server does not support it.
 type Capabilities struct {
-	Tools     bool `json:"tools"`
-	Resources bool `json:"resources"`
+	Experimental map[string]any         `json:"experimental,omitempty"`
+	Logging      *LoggingCapability     `json:"logging,omitempty"`
+	Completions  *CompletionsCapability `json:"completions,omitempty"`
+	Prompts      *PromptsCapability     `json:"prompts,omitempty"`
+	Resources    *ResourcesCapability   `json:"resources,omitempty"`
+	Tools        *ToolsCapability       `json:"tools,omitempty"`
 }
 
+// LoggingCapability represents the server's support for sending log messages.
+type LoggingCapability struct{}
+
+// CompletionsCapability represents the server's support for argument autocompletion.
+type CompletionsCapability struct{}
+
+// PromptsCapability represents the server's support for prompts.
+type PromptsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// ResourcesCapability represents the server's support for resources.
+type ResourcesCapability struct {
+	Subscribe   bool `json:"subscribe,omitempty"`
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// ToolsCapability represents the server's support for tools.
+type ToolsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// ClientCapabilities represents the capabilities advertised by the client.
+// A nil capability means that the client does not support it.
+type ClientCapabilities struct {
+	Roots        *RootsCapability       `json:"roots,omitempty"`
+	Sampling     *SamplingCapability    `json:"sampling,omitempty"`
+	Elicitation  *ElicitationCapability `json:"elicitation,omitempty"`
+	Experimental map[string]any         `json:"experimental,omitempty"`
+}
+
+// RootsCapability represents the client's support for roots.
+type RootsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// SamplingCapability represents the client's support for sampling.
+type SamplingCapability struct{}
+
+// ElicitationCapability represents the client's support for elicitation.
+type ElicitationCapability struct{}
+
 // InitializeParams represents the parameters for the initialize request.
 type InitializeParams struct {
-	ClientInfo ClientInfo `json:"clientInfo"`
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    ClientCapabilities `json:"capabilities"`
+	ClientInfo      ClientInfo         `json:"clientInfo"`
 }
 
 // InitializeResult represents the result of the initialize request.
 type InitializeResult struct {
-	ServerInfo   ServerInfo   `json:"serverInfo"`
-	Capabilities Capabilities `json:"capabilities"`
+	ProtocolVersion string       `json:"protocolVersion"`
+	ServerInfo      ServerInfo   `json:"serverInfo"`
+	Capabilities    Capabilities `json:"capabilities"`
+	Instructions    string       `json:"instructions,omitempty"`
+}
+
+// ProtocolVersionErrorData represents the data of the error returned when no protocol version
+// supported by both the client and the server can be negotiated.
+type ProtocolVersionErrorData struct {
+	Supported []string `json:"supported"`
+	Requested string   `json:"requested"`
 }
 
 // NewInitializeParams creates a new InitializeParams instance.
-func NewInitializeParams(name, version string) *InitializeParams {
-	return &InitializeParams{ClientInfo: ClientInfo{Name: name, Version: version}}
+func NewInitializeParams(protocolVersion, name, version string) *InitializeParams {
+	return &InitializeParams{ProtocolVersion: protocolVersion, ClientInfo: ClientInfo{Name: name, Version: version}}
 }
 
 // NewInitializeResult creates a new InitializeResult instance.
-func NewInitializeResult(name, version string, tools, resources bool) *InitializeResult {
+func NewInitializeResult(protocolVersion, name, version string, capabilities Capabilities, instructions string) *InitializeResult {
 	return &InitializeResult{
-		ServerInfo:   ServerInfo{Name: name, Version: version},
-		Capabilities: Capabilities{Tools: tools, Resources: resources},
+		ProtocolVersion: protocolVersion,
+		ServerInfo:      ServerInfo{Name: name, Version: version},
+		Capabilities:    capabilities,
+		Instructions:    instructions,
 	}
 }
@@ -1,17 +1,44 @@
 package types
 
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+var ErrInvalidId = errors.New("id must be a string, a number or null")
+
+// RequestId represents a JSON-RPC request id. It can be a string, a number or null
+// and is kept exactly as sent by the client, so that it can be echoed back unchanged.
+type RequestId struct {
+	raw json.RawMessage
+}
+
 // JSONRPCRequest represents a JSON-RPC request object.
 type JSONRPCRequest struct {
+	JSONRPC string    `json:"jsonrpc"`
+	Id      RequestId `json:"id,omitzero"`
+	Method  string    `json:"method"`
+	Params  any       `json:"params,omitempty"`
+}
+
+// JSONRPCNotification represents a JSON-RPC notification object, i.e. a request without id
+// that must not be answered.
+type JSONRPCNotification struct {
 	JSONRPC string `json:"jsonrpc"`
-	Id      string `json:"id"`
 	Method  string `json:"method"`
 	Params  any    `json:"params,omitempty"`
 }
 
+// NotificationHandler defines the function signature for notification handlers.
+// It takes the params of the received notification.
+type NotificationHandler func(params any)
+
 // JSONRPCResponse represents a JSON-RPC response object.
 type JSONRPCResponse struct {
 	JSONRPC string           `json:"jsonrpc"`
-	Id      string           `json:"id"`
+	Id      RequestId        `json:"id"`
 	Result  any              `json:"result,omitempty"`
 	Error   *JSONRPCErrorObj `json:"error,omitempty"`
 }
@@ -24,12 +51,22 @@ type JSONRPCErrorObj struct {
 }
 
 // NewJSONRPCRequest creates a new JSON-RPC request object.
-func NewJSONRPCRequest(id, method string, params any) *JSONRPCRequest {
+func NewJSONRPCRequest(id RequestId, method string, params any) *JSONRPCRequest {
 	return &JSONRPCRequest{JSONRPC: "2.0", Id: id, Method: method, Params: params}
 }
 
+// NewJSONRPCNotification creates a new JSON-RPC notification object.
+func NewJSONRPCNotification(method string, params any) *JSONRPCNotification {
+	return &JSONRPCNotification{JSONRPC: "2.0", Method: method, Params: params}
+}
+
+// IsNotification reports whether the request is a notification, i.e. it has no id.
+func (r *JSONRPCRequest) IsNotification() bool {
+	return r.Id.IsZero()
+}
+
 // NewJSONRPCResponse creates a new JSON-RPC response object.
-func NewJSONRPCResponse(id string, result any, err *JSONRPCErrorObj) *JSONRPCResponse {
+func NewJSONRPCResponse(id RequestId, result any, err *JSONRPCErrorObj) *JSONRPCResponse {
 	return &JSONRPCResponse{JSONRPC: "2.0", Id: id, Result: result, Error: err}
 }
 
@@ -37,3 +74,61 @@ func NewJSONRPCResponse(id string, result any, err *JSONRPCErrorObj) *JSONRPCRes
 func NewJSONRPCErrorObj(code int, msg string, data any) *JSONRPCErrorObj {
 	return &JSONRPCErrorObj{Code: code, Message: msg, Data: data}
 }
+
+// NewStringId creates a new RequestId holding a string.
+func NewStringId(id string) RequestId {
+	raw, _ := json.Marshal(id)
+	return RequestId{raw}
+}
+
+// NewNumberId creates a new RequestId holding an integer number.
+func NewNumberId(id int64) RequestId {
+	return RequestId{json.RawMessage(strconv.FormatInt(id, 10))}
+}
+
+// NullId creates a new RequestId holding null.
+func NullId() RequestId {
+	return RequestId{json.RawMessage("null")}
+}
+
+// IsZero reports whether the id is absent, as opposed to being explicitly set (even to null).
+func (id RequestId) IsZero() bool {
+	return id.raw == nil
+}
+
+// IsNull reports whether the id is absent or null.
+func (id RequestId) IsNull() bool {
+	return id.raw == nil || string(id.raw) == "null"
+}
+
+// String returns the JSON representation of the id.
+func (id RequestId) String() string {
+	if id.raw == nil {
+		return "null"
+	}
+	return string(id.raw)
+}
+
+// MarshalJSON implements json.Marshaler. An absent id is marshaled as null.
+func (id RequestId) MarshalJSON() ([]byte, error) {
+	if id.raw == nil {
+		return []byte("null"), nil
+	}
+	return id.raw, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting only strings, numbers and null.
+func (id *RequestId) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return ErrInvalidId
+	}
+
+	switch data[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		id.raw = append(json.RawMessage(nil), data...)
+		return nil
+	default:
+		return ErrInvalidId
+	}
+}
@@ -0,0 +1,27 @@
+package types
+
+// CancelledParams represents the parameters of the notifications/cancelled notification,
+// sent to cancel a request in progress.
+type CancelledParams struct {
+	RequestId RequestId `json:"requestId"`
+	Reason    string    `json:"reason,omitempty"`
+}
+
+// ProgressParams represents the parameters of the notifications/progress notification,
+// reporting the progress of the request that sent the progress token.
+type ProgressParams struct {
+	ProgressToken any     `json:"progressToken"`
+	Progress      float64 `json:"progress"`
+	Total         float64 `json:"total,omitempty"`
+	Message       string  `json:"message,omitempty"`
+}
+
+// NewCancelledParams creates a new CancelledParams with the given request id and reason.
+func NewCancelledParams(requestId RequestId, reason string) *CancelledParams {
+	return &CancelledParams{RequestId: requestId, Reason: reason}
+}
+
+// NewProgressParams creates a new ProgressParams with the given parameters.
+func NewProgressParams(progressToken any, progress, total float64, message string) *ProgressParams {
+	return &ProgressParams{ProgressToken: progressToken, Progress: progress, Total: total, Message: message}
+}
@@ -0,0 +1,78 @@
+package types
+
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// PromptHandler defines the function signature for prompt handlers.
+// It takes the prompt arguments and returns the messages of the prompt.
+type PromptHandler func(map[string]string) ([]PromptMessage, error)
+
+// PromptArgument represents an argument accepted by a prompt template.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+}
+
+// Prompt represents a prompt template that can be retrieved via the MCP protocol.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+	Get         PromptHandler    `json:"-"`
+}
+
+// PromptMessage represents a role-tagged message returned by a prompt.
+type PromptMessage struct {
+	Role    string           `json:"role"` // user, assistant
+	Content OperationContent `json:"content"`
+}
+
+// ListPromptsResult represents the result of listing available prompts.
+type ListPromptsResult struct {
+	Prompts []Prompt `json:"prompts"`
+}
+
+// GetPromptParams represents the parameters for getting a prompt.
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// GetPromptResult represents the result of getting a prompt.
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// NewPrompt creates a new Prompt with the given parameters.
+func NewPrompt(name, description string, arguments []PromptArgument, handler PromptHandler) *Prompt {
+	return &Prompt{name, description, arguments, handler}
+}
+
+// NewPromptArgument creates a new PromptArgument with the given parameters.
+func NewPromptArgument(name, description string, required bool) *PromptArgument {
+	return &PromptArgument{name, description, required}
+}
+
+// NewPromptMessage creates a new PromptMessage with the given role and content.
+func NewPromptMessage(role string, content OperationContent) *PromptMessage {
+	return &PromptMessage{role, content}
+}
+
+// NewListPromptsResult creates a new ListPromptsResult with the given prompts.
+func NewListPromptsResult(prompts []Prompt) *ListPromptsResult {
+	return &ListPromptsResult{prompts}
+}
+
+// NewGetPromptParams creates a new GetPromptParams with the given name and arguments.
+func NewGetPromptParams(name string, arguments map[string]string) *GetPromptParams {
+	return &GetPromptParams{name, arguments}
+}
+
+// NewGetPromptResult creates a new GetPromptResult with the given description and messages.
+func NewGetPromptResult(description string, messages []PromptMessage) *GetPromptResult {
+	return &GetPromptResult{description, messages}
+}
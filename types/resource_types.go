@@ -1,8 +1,21 @@
 package types
 
+import "context"
+
 // ResourceReader defines a function type for reading resource content.
 type ResourceReader func(uri string) ([]OperationContent, error)
 
+// ContextResourceReader is a ResourceReader that also takes the context of the request,
+// cancelled when the request is cancelled or the session is closed.
+type ContextResourceReader func(ctx context.Context, uri string) ([]OperationContent, error)
+
+// ResourceTemplateReader defines a function type for reading the content of a resource matched by a template.
+// It takes the requested URI and the variables extracted from it.
+type ResourceTemplateReader func(uri string, vars map[string]string) ([]OperationContent, error)
+
+// ContextResourceTemplateReader is a ResourceTemplateReader that also takes the context of the request.
+type ContextResourceTemplateReader func(ctx context.Context, uri string, vars map[string]string) ([]OperationContent, error)
+
 // ListResourcesResult represents the result of listing resources.
 type ListResourcesResult struct {
 	Resources []Resource `json:"resources"`
@@ -10,10 +23,25 @@ type ListResourcesResult struct {
 
 // Resource represents a resource with its metadata and read function.
 type Resource struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	URI         string         `json:"uri"` // e.g.: file:///path/to/file.txt
-	Read        ResourceReader `json:"-"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	URI         string                `json:"uri"` // e.g.: file:///path/to/file.txt
+	Read        ResourceReader        `json:"-"`
+	ReadContext ContextResourceReader `json:"-"` // used instead of Read when set
+}
+
+// ResourceTemplate represents a family of resources identified by an RFC 6570 URI template.
+type ResourceTemplate struct {
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	URITemplate string                        `json:"uriTemplate"` // e.g.: file:///{+path}
+	Read        ResourceTemplateReader        `json:"-"`
+	ReadContext ContextResourceTemplateReader `json:"-"` // used instead of Read when set
+}
+
+// ListResourceTemplatesResult represents the result of listing resource templates.
+type ListResourceTemplatesResult struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
 }
 
 // ReadResourceParams represents the parameters for reading a resource.
@@ -31,11 +59,41 @@ func NewResource(name, description, uri string, reader ResourceReader) *Resource
 	return &Resource{Name: name, Description: description, URI: uri, Read: reader}
 }
 
+// NewContextResource creates a new Resource whose reader takes the context of the request.
+// Read is set too, invoking the reader with a background context.
+func NewContextResource(name, description, uri string, reader ContextResourceReader) *Resource {
+	read := func(uri string) ([]OperationContent, error) {
+		return reader(context.Background(), uri)
+	}
+
+	return &Resource{Name: name, Description: description, URI: uri, Read: read, ReadContext: reader}
+}
+
 // NewListResourcesResult creates a new ListResourcesResult with the given resources.
 func NewListResourcesResult(resources []Resource) *ListResourcesResult {
 	return &ListResourcesResult{Resources: resources}
 }
 
+// NewResourceTemplate creates a new ResourceTemplate with the given parameters.
+func NewResourceTemplate(name, description, uriTemplate string, reader ResourceTemplateReader) *ResourceTemplate {
+	return &ResourceTemplate{Name: name, Description: description, URITemplate: uriTemplate, Read: reader}
+}
+
+// NewContextResourceTemplate creates a new ResourceTemplate whose reader takes the context of the request.
+// Read is set too, invoking the reader with a background context.
+func NewContextResourceTemplate(name, description, uriTemplate string, reader ContextResourceTemplateReader) *ResourceTemplate {
+	read := func(uri string, vars map[string]string) ([]OperationContent, error) {
+		return reader(context.Background(), uri, vars)
+	}
+
+	return &ResourceTemplate{Name: name, Description: description, URITemplate: uriTemplate, Read: read, ReadContext: reader}
+}
+
+// NewListResourceTemplatesResult creates a new ListResourceTemplatesResult with the given templates.
+func NewListResourceTemplatesResult(templates []ResourceTemplate) *ListResourceTemplatesResult {
+	return &ListResourceTemplatesResult{ResourceTemplates: templates}
+}
+
 // NewReadResourceParams creates a new ReadResourceParams with the given URI.
 func NewReadResourceParams(uri string) *ReadResourceParams {
 	return &ReadResourceParams{URI: uri}
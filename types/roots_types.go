@@ -0,0 +1,22 @@
+package types
+
+// Root represents a directory or file the client exposes to the server, identified by a file:// URI.
+type Root struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// ListRootsResult represents the result of the roots/list request sent to the client.
+type ListRootsResult struct {
+	Roots []Root `json:"roots"`
+}
+
+// NewRoot creates a new Root with the given URI and name.
+func NewRoot(uri, name string) *Root {
+	return &Root{URI: uri, Name: name}
+}
+
+// NewListRootsResult creates a new ListRootsResult with the given roots.
+func NewListRootsResult(roots []Root) *ListRootsResult {
+	return &ListRootsResult{Roots: roots}
+}
@@ -0,0 +1,57 @@
+package types
+
+// SamplingMessage represents a message of the conversation sent to the LLM of the client.
+type SamplingMessage struct {
+	Role    string           `json:"role"`
+	Content OperationContent `json:"content"`
+}
+
+// ModelHint represents a hint for the model selection, e.g. a model name or family.
+type ModelHint struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ModelPreferences represents the preferences of the server for the model selection of the client.
+// Priorities are between 0 and 1.
+type ModelPreferences struct {
+	Hints                []ModelHint `json:"hints,omitempty"`
+	CostPriority         *float64    `json:"costPriority,omitempty"`
+	SpeedPriority        *float64    `json:"speedPriority,omitempty"`
+	IntelligencePriority *float64    `json:"intelligencePriority,omitempty"`
+}
+
+// CreateMessageParams represents the parameters of the sampling/createMessage request,
+// asking the client to generate a message with its LLM.
+type CreateMessageParams struct {
+	Messages         []SamplingMessage `json:"messages"`
+	ModelPreferences *ModelPreferences `json:"modelPreferences,omitempty"`
+	SystemPrompt     string            `json:"systemPrompt,omitempty"`
+	IncludeContext   string            `json:"includeContext,omitempty"` // none, thisServer or allServers
+	Temperature      *float64          `json:"temperature,omitempty"`
+	MaxTokens        int               `json:"maxTokens"`
+	StopSequences    []string          `json:"stopSequences,omitempty"`
+	Metadata         any               `json:"metadata,omitempty"`
+}
+
+// CreateMessageResult represents the message generated by the LLM of the client.
+type CreateMessageResult struct {
+	Role       string           `json:"role"`
+	Content    OperationContent `json:"content"`
+	Model      string           `json:"model"`
+	StopReason string           `json:"stopReason,omitempty"` // e.g. endTurn, stopSequence, maxTokens
+}
+
+// NewSamplingMessage creates a new SamplingMessage with the given role and content.
+func NewSamplingMessage(role string, content OperationContent) *SamplingMessage {
+	return &SamplingMessage{Role: role, Content: content}
+}
+
+// NewCreateMessageParams creates a new CreateMessageParams with the given messages and maximum number of tokens.
+func NewCreateMessageParams(messages []SamplingMessage, maxTokens int) *CreateMessageParams {
+	return &CreateMessageParams{Messages: messages, MaxTokens: maxTokens}
+}
+
+// NewCreateMessageResult creates a new CreateMessageResult with the given parameters.
+func NewCreateMessageResult(role string, content OperationContent, model, stopReason string) *CreateMessageResult {
+	return &CreateMessageResult{Role: role, Content: content, Model: model, StopReason: stopReason}
+}
@@ -1,15 +1,26 @@
 package types
 
+import (
+	"context"
+	"encoding/json"
+)
+
 // ToolHandler defines the function signature for tool execution handlers.
 // It takes an input a struct containing the parameters of the function.
 type ToolHandler func(map[string]any) (*ToolResult, error)
 
+// ContextToolHandler is a ToolHandler that also takes the context of the request,
+// cancelled when the request is cancelled or the session is closed.
+type ContextToolHandler func(ctx context.Context, args map[string]any) (*ToolResult, error)
+
 // Tool represents a tool that can be called via the MCP protocol.
 type Tool struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	InputSchema map[string]any `json:"inputSchema"`
-	Run         ToolHandler    `json:"-"`
+	Name         string             `json:"name"`
+	Description  string             `json:"description"`
+	InputSchema  map[string]any     `json:"inputSchema"`
+	OutputSchema map[string]any     `json:"outputSchema,omitempty"`
+	Run          ToolHandler        `json:"-"`
+	RunContext   ContextToolHandler `json:"-"` // used instead of Run when set
 }
 
 // ListToolsResult represents the result of listing available tools.
@@ -25,12 +36,31 @@ type CallToolParams struct {
 
 // ToolResult represents the result returned by a tool execution.
 type ToolResult struct {
-	Content []OperationContent `json:"content"`
+	Content           []OperationContent `json:"content"`
+	StructuredContent any                `json:"structuredContent,omitempty"`
+	IsError           bool               `json:"isError,omitempty"`
+}
+
+// SchemaViolation represents a value not satisfying a JSON Schema keyword.
+type SchemaViolation struct {
+	Path    string `json:"path"`    // JSON Pointer to the invalid value, e.g. /items/0/name
+	Keyword string `json:"keyword"` // the failing keyword, e.g. required, type, minimum
+	Message string `json:"message"`
 }
 
 // NewTool creates a new Tool with the given parameters.
 func NewTool(name, description string, inputSchema map[string]any, handler ToolHandler) *Tool {
-	return &Tool{name, description, inputSchema, handler}
+	return &Tool{Name: name, Description: description, InputSchema: inputSchema, Run: handler}
+}
+
+// NewContextTool creates a new Tool whose handler takes the context of the request.
+// Run is set too, invoking the handler with a background context.
+func NewContextTool(name, description string, inputSchema map[string]any, handler ContextToolHandler) *Tool {
+	run := func(args map[string]any) (*ToolResult, error) {
+		return handler(context.Background(), args)
+	}
+
+	return &Tool{Name: name, Description: description, InputSchema: inputSchema, Run: run, RunContext: handler}
 }
 
 // NewListToolsResult creates a new ListToolsResult with the given tools.
@@ -45,10 +75,34 @@ func NewCallToolParams(name string, arguments map[string]any) *CallToolParams {
 
 // NewToolResult creates a new ToolResult with the given content.
 func NewToolResult(content []OperationContent) *ToolResult {
-	return &ToolResult{content}
+	return &ToolResult{Content: content}
+}
+
+// NewToolErrorResult creates a new ToolResult reporting a tool failure with the given content.
+func NewToolErrorResult(content []OperationContent) *ToolResult {
+	return &ToolResult{Content: content, IsError: true}
+}
+
+// NewStructuredToolResult creates a new ToolResult with the given structured content,
+// serialized to JSON as text content for the clients not supporting structured content.
+func NewStructuredToolResult(structuredContent any) (*ToolResult, error) {
+	text, err := json.Marshal(structuredContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ToolResult{
+		Content:           []OperationContent{*NewOperationContent("text", string(text), "", nil)},
+		StructuredContent: structuredContent,
+	}, nil
 }
 
 // NewOperationContent creates a new OperationContent with the given parameters.
 func NewOperationContent(_type, text, uri string, data any) *OperationContent {
 	return &OperationContent{_type, text, data, uri}
 }
+
+// NewSchemaViolation creates a new SchemaViolation with the given parameters.
+func NewSchemaViolation(path, keyword, message string) *SchemaViolation {
+	return &SchemaViolation{path, keyword, message}
+}